@@ -0,0 +1,176 @@
+// Package client helps services call this API with machine-to-machine
+// tokens obtained through the OAuth2 client credentials grant.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultLeeway is how long before expiry a cached token is replaced.
+const defaultLeeway = time.Minute
+
+// defaultExpiresIn is the lifetime assumed for a token returned without
+// expires_in.
+const defaultExpiresIn = 5 * time.Minute
+
+// ClientCredentials performs the client_credentials grant against a token
+// endpoint and caches the access token until shortly before it expires.
+// It is safe for concurrent use; callers racing on an expired token share
+// a single request to the token endpoint.
+type ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Audience     string
+
+	// HTTPClient defaults to a client with a ten second timeout.
+	HTTPClient *http.Client
+	// Leeway defaults to one minute. It is capped at half the lifetime of
+	// the token, so short-lived tokens are still reused.
+	Leeway time.Duration
+
+	mu        sync.Mutex
+	token     string
+	refreshAt time.Time
+}
+
+// BaseURL returns the URL of the Auth0 tenant at domain, without a
+// trailing slash. domain may carry its own scheme, which lets it point at
+// a local stand-in for the tenant.
+func BaseURL(domain string) string {
+	if !strings.Contains(domain, "://") {
+		domain = "https://" + domain
+	}
+	return strings.TrimSuffix(domain, "/")
+}
+
+// NewClientCredentials creates ClientCredentials for the Auth0 tenant at
+// domain, see BaseURL.
+func NewClientCredentials(domain string, clientID string, clientSecret string, audience string) *ClientCredentials {
+	return &ClientCredentials{
+		TokenURL:     BaseURL(domain) + "/oauth/token",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Audience:     audience,
+	}
+}
+
+// Token returns a valid access token, requesting a new one when the
+// cached token is missing or about to expire.
+func (cc *ClientCredentials) Token(ctx context.Context) (string, error) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	if cc.token != "" && time.Now().Before(cc.refreshAt) {
+		return cc.token, nil
+	}
+
+	token, expiresIn, err := cc.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	if expiresIn <= 0 {
+		expiresIn = defaultExpiresIn
+	}
+
+	leeway := cc.Leeway
+	if leeway == 0 {
+		leeway = defaultLeeway
+	}
+	if leeway > expiresIn/2 {
+		leeway = expiresIn / 2
+	}
+
+	cc.token = token
+	cc.refreshAt = time.Now().Add(expiresIn - leeway)
+
+	return cc.token, nil
+}
+
+// Client returns an *http.Client that adds the access token as a bearer
+// Authorization header to every request.
+func (cc *ClientCredentials) Client() *http.Client {
+	return &http.Client{
+		Transport: &transport{credentials: cc, base: http.DefaultTransport},
+		Timeout:   cc.httpClient().Timeout,
+	}
+}
+
+func (cc *ClientCredentials) httpClient() *http.Client {
+	if cc.HTTPClient != nil {
+		return cc.HTTPClient
+	}
+	return &http.Client{Timeout: 10 * time.Second}
+}
+
+func (cc *ClientCredentials) fetch(ctx context.Context) (string, time.Duration, error) {
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {cc.ClientID},
+		"client_secret": {cc.ClientSecret},
+	}
+	if cc.Audience != "" {
+		form.Set("audience", cc.Audience)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cc.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+
+	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+
+	res, err := cc.httpClient().Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("client credentials grant failed with %s: %s", res.Status, body)
+	}
+
+	var output struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &output); err != nil {
+		return "", 0, err
+	}
+
+	if output.AccessToken == "" {
+		return "", 0, fmt.Errorf("client credentials grant returned no access token")
+	}
+
+	return output.AccessToken, time.Duration(output.ExpiresIn) * time.Second, nil
+}
+
+type transport struct {
+	credentials *ClientCredentials
+	base        http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.credentials.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,170 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewClientCredentialsTokenURL(t *testing.T) {
+	tests := []struct {
+		domain string
+		want   string
+	}{
+		{"tenant.auth0.com", "https://tenant.auth0.com/oauth/token"},
+		{"https://tenant.auth0.com/", "https://tenant.auth0.com/oauth/token"},
+		{"http://127.0.0.1:8080", "http://127.0.0.1:8080/oauth/token"},
+	}
+
+	for _, tt := range tests {
+		if got := NewClientCredentials(tt.domain, "id", "secret", "").TokenURL; got != tt.want {
+			t.Errorf("NewClientCredentials(%q).TokenURL = %q, want %q", tt.domain, got, tt.want)
+		}
+	}
+}
+
+func TestBaseURL(t *testing.T) {
+	tests := []struct {
+		domain string
+		want   string
+	}{
+		{"tenant.auth0.com", "https://tenant.auth0.com"},
+		{"https://tenant.auth0.com/", "https://tenant.auth0.com"},
+		{"http://127.0.0.1:8080", "http://127.0.0.1:8080"},
+	}
+
+	for _, tt := range tests {
+		if got := BaseURL(tt.domain); got != tt.want {
+			t.Errorf("BaseURL(%q) = %q, want %q", tt.domain, got, tt.want)
+		}
+	}
+}
+
+// newTokenServer answers the client credentials grant with tokens named
+// after the number of requests so far, that expire after expiresIn
+// seconds. A negative expiresIn leaves expires_in out.
+func newTokenServer(t *testing.T, expiresIn int, delay time.Duration) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		time.Sleep(delay)
+
+		output := map[string]interface{}{"access_token": fmt.Sprintf("m2m-token-%d", n)}
+		if expiresIn >= 0 {
+			output["expires_in"] = expiresIn
+		}
+		_ = json.NewEncoder(w).Encode(output)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+func TestConcurrentCallersShareOneRequest(t *testing.T) {
+	server, requests := newTokenServer(t, 3600, 50*time.Millisecond)
+	cc := NewClientCredentials(server.URL, "id", "secret", "")
+
+	var wg sync.WaitGroup
+	tokens := make([]string, 10)
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			token, err := cc.Token(context.Background())
+			if err != nil {
+				t.Error(err)
+			}
+			tokens[i] = token
+		}(i)
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(requests); n != 1 {
+		t.Errorf("token endpoint called %d times, want 1", n)
+	}
+	for _, token := range tokens {
+		if token != "m2m-token-1" {
+			t.Errorf("tokens = %v, want m2m-token-1 for every caller", tokens)
+			break
+		}
+	}
+}
+
+func TestTokenIsRefreshedAfterExpiry(t *testing.T) {
+	// With a one second lifetime the leeway is capped at half a second.
+	server, requests := newTokenServer(t, 1, 0)
+	cc := NewClientCredentials(server.URL, "id", "secret", "")
+
+	first, err := cc.Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := cc.Token(context.Background()); again != first {
+		t.Errorf("token = %q before expiry, want the cached %q", again, first)
+	}
+
+	time.Sleep(600 * time.Millisecond)
+
+	refreshed, err := cc.Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed == first || atomic.LoadInt32(requests) != 2 {
+		t.Errorf("token = %q after %d requests, want a new one", refreshed, atomic.LoadInt32(requests))
+	}
+}
+
+func TestTokenWithoutExpiresInIsCached(t *testing.T) {
+	server, requests := newTokenServer(t, -1, 0)
+	cc := NewClientCredentials(server.URL, "id", "secret", "")
+
+	for i := 0; i < 2; i++ {
+		if _, err := cc.Token(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := atomic.LoadInt32(requests); n != 1 {
+		t.Errorf("token endpoint called %d times, want 1", n)
+	}
+}
+
+func TestTokenIsCached(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/oauth/token" {
+			http.NotFound(w, r)
+			return
+		}
+		requests++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "m2m-token",
+			"expires_in":   3600,
+		})
+	}))
+	defer server.Close()
+
+	cc := NewClientCredentials(server.URL, "id", "secret", "https://books.example.com")
+
+	for i := 0; i < 2; i++ {
+		token, err := cc.Token(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "m2m-token" {
+			t.Errorf("token = %q, want m2m-token", token)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("token endpoint called %d times, want 1", requests)
+	}
+}
@@ -0,0 +1,281 @@
+// controllers/apikeys.go
+
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/fahmiyonda007/go-gin-gorm/middleware"
+	"github.com/fahmiyonda007/go-gin-gorm/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// keyPrefix marks our API keys so they are easy to spot in logs and
+// secret scanners.
+const keyPrefix = "ak_"
+
+// APIKeyController serves the api keys routes.
+type APIKeyController struct {
+	db         *gorm.DB
+	authorizer *middleware.Authorizer
+}
+
+// NewAPIKeyController creates an APIKeyController on db. It asks
+// authorizer which permissions the caller may grant.
+func NewAPIKeyController(db *gorm.DB, authorizer *middleware.Authorizer) *APIKeyController {
+	return &APIKeyController{db: db, authorizer: authorizer}
+}
+
+//	@BasePath	/api/v1
+//
+// APIKeys godoc
+//
+//	@Summary	get all api keys
+//	@Schemes
+//	@Description	get all api keys of the caller's organization
+//	@Tags			API Keys
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		APIKeyOutput
+//	@Failure		401	{object}	handler.JSONResult
+//	@Router			/admin/api-keys [get]
+//	@Security		BearerAuth
+func (ctl *APIKeyController) APIKeys(c *gin.Context) {
+	db := ctl.db.WithContext(middleware.TenantContext(c))
+
+	var apiKeys []models.APIKey
+	if err := db.Order("id").Find(&apiKeys).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":  http.StatusInternalServerError,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	output := make([]APIKeyOutput, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		output = append(output, newAPIKeyOutput(apiKey))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": output})
+}
+
+//	@BasePath	/api/v1
+//
+// APIKeys/:id godoc
+//
+//	@Summary	find an api key by id
+//	@Schemes
+//	@Description	find an api key by id
+//	@Tags			API Keys
+//	@Param			id	path	int	true	"id"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	APIKeyOutput
+//	@Router			/admin/api-keys/{id} [get]
+//	@Security		BearerAuth
+func (ctl *APIKeyController) APIKey(c *gin.Context) {
+	db := ctl.db.WithContext(middleware.TenantContext(c))
+
+	var apiKey models.APIKey
+	if err := db.Where("id = ?", c.Param("id")).First(&apiKey).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Record not found!"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": newAPIKeyOutput(apiKey)})
+}
+
+//	@BasePath	/api/v1
+//
+// APIKeys godoc
+//
+//	@Summary	Create new api key
+//	@Schemes
+//	@Description	Create new api key, the key itself is only returned by this call
+//	@Tags			API Keys
+//	@Param			input	body	CreateAPIKeyInput	true	"Input"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	CreatedAPIKeyOutput
+//	@Failure		400	{object}	handler.JSONResult
+//	@Failure		403	{object}	handler.JSONResult
+//	@Router			/admin/api-keys [post]
+//	@Security		BearerAuth
+func (ctl *APIKeyController) CreateAPIKey(c *gin.Context) {
+	db := ctl.db.WithContext(middleware.TenantContext(c))
+
+	// Validate input
+	var input CreateAPIKeyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": err.Error(),
+		})
+		return
+	}
+	if !ctl.checkPermissions(c, input.Permissions) {
+		return
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":  http.StatusInternalServerError,
+			"error": err.Error(),
+		})
+		return
+	}
+	key := keyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	// Create api key
+	apiKey := models.APIKey{
+		Name:        input.Name,
+		Prefix:      key[:len(keyPrefix)+6],
+		KeyHash:     models.HashAPIKey(key),
+		Permissions: input.Permissions,
+		ExpiresAt:   input.ExpiresAt,
+		OwnerSub:    middleware.Subject(c),
+	}
+	if err := db.Create(&apiKey).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":  http.StatusInternalServerError,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": CreatedAPIKeyOutput{
+		APIKeyOutput: newAPIKeyOutput(apiKey),
+		Key:          key,
+	}})
+}
+
+//	@BasePath	/api/v1
+//
+// APIKeys godoc
+//
+//	@Summary	Update an api key
+//	@Schemes
+//	@Description	Update the name, permissions or expiry of an api key
+//	@Tags			API Keys
+//	@Param			id		path	int					true	"id"
+//	@Param			input	body	UpdateAPIKeyInput	false	"Input"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	APIKeyOutput
+//	@Failure		400	{object}	handler.JSONResult
+//	@Failure		403	{object}	handler.JSONResult
+//	@Router			/admin/api-keys/{id} [patch]
+//	@Security		BearerAuth
+func (ctl *APIKeyController) UpdateAPIKey(c *gin.Context) {
+	db := ctl.db.WithContext(middleware.TenantContext(c))
+
+	// Get model if exist
+	var apiKey models.APIKey
+	if err := db.Where("id = ?", c.Param("id")).First(&apiKey).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":  http.StatusNotFound,
+			"error": "Record not found!",
+		})
+		return
+	}
+
+	// Validate input
+	var input UpdateAPIKeyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if input.Name != "" {
+		apiKey.Name = input.Name
+	}
+	if input.Permissions != nil {
+		if !ctl.checkPermissions(c, input.Permissions) {
+			return
+		}
+		apiKey.Permissions = input.Permissions
+	}
+	if input.ExpiresAt != nil {
+		apiKey.ExpiresAt = input.ExpiresAt
+	}
+
+	if err := db.Save(&apiKey).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":  http.StatusInternalServerError,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": newAPIKeyOutput(apiKey)})
+}
+
+//	@BasePath	/api/v1
+//
+// APIKeys godoc
+//
+//	@Summary	Delete an api key
+//	@Schemes
+//	@Description	Delete an api key, requests using it are rejected right away
+//	@Tags			API Keys
+//	@Param			id	path	int	true	"id"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	handler.JSONResult
+//	@Router			/admin/api-keys/{id} [delete]
+//	@Security		BearerAuth
+func (ctl *APIKeyController) DeleteAPIKey(c *gin.Context) {
+	db := ctl.db.WithContext(middleware.TenantContext(c))
+
+	// Get model if exist
+	var apiKey models.APIKey
+	if err := db.Where("id = ?", c.Param("id")).First(&apiKey).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":  http.StatusNotFound,
+			"error": "Record not found!",
+		})
+		return
+	}
+
+	if err := db.Delete(&apiKey).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":  http.StatusInternalServerError,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": true})
+}
+
+// checkPermissions makes sure a key only gets permissions the routes
+// check, by name, and that its creator holds themselves. It answers the
+// request and returns false otherwise.
+func (ctl *APIKeyController) checkPermissions(c *gin.Context, permissions []string) bool {
+	for _, permission := range permissions {
+		if permission == middleware.UserAdminPermission || !slices.Contains(middleware.Permissions, permission) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":  http.StatusBadRequest,
+				"error": fmt.Sprintf("%q can't be granted to an api key", permission),
+			})
+			return false
+		}
+		if !ctl.authorizer.HasPermission(c, permission) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"code":  http.StatusForbidden,
+				"error": fmt.Sprintf("You can't grant %q, you don't hold it", permission),
+			})
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,200 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/fahmiyonda007/go-gin-gorm/middleware"
+	"github.com/fahmiyonda007/go-gin-gorm/migrations"
+	"github.com/fahmiyonda007/go-gin-gorm/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := models.Open(models.DatabaseConfig{Driver: "sqlite", Name: ":memory:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+// newTestRouter serves the API key routes on db. The admin calling them
+// belongs to the organization in the X-Org test header and may read books
+// and authors.
+func newTestRouter(db *gorm.DB) *gin.Engine {
+	ctl := NewAPIKeyController(db, middleware.NewAuthorizer(middleware.AuthorizerConfig{}))
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		middleware.SetClaims(c, "auth0|admin", middleware.CustomClaims{
+			OrgID:       c.GetHeader("X-Org"),
+			Permissions: []string{middleware.AdminPermission, "read:book", "read:author"},
+		})
+	})
+	r.GET("/api-keys", ctl.APIKeys)
+	r.GET("/api-keys/:id", ctl.APIKey)
+	r.POST("/api-keys", ctl.CreateAPIKey)
+	r.PATCH("/api-keys/:id", ctl.UpdateAPIKey)
+	r.DELETE("/api-keys/:id", ctl.DeleteAPIKey)
+
+	return r
+}
+
+func request(r http.Handler, method string, path string, body string, org string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("X-Org", org)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func createAPIKey(t *testing.T, r http.Handler, org string) CreatedAPIKeyOutput {
+	t.Helper()
+
+	w := request(r, http.MethodPost, "/api-keys", `{"name":"ci","permissions":["read:book"]}`, org)
+	if w.Code != http.StatusOK {
+		t.Fatalf("create: status = %d, want 200: %s", w.Code, w.Body)
+	}
+
+	var body struct {
+		Data CreatedAPIKeyOutput `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	return body.Data
+}
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	r := newTestRouter(openTestDB(t))
+	created := createAPIKey(t, r, "o1")
+	path := "/api-keys/" + strconv.FormatUint(uint64(created.ID), 10)
+
+	if created.Key == "" || !strings.HasPrefix(created.Key, created.Prefix) {
+		t.Errorf("created %+v, want the plain key starting with its prefix", created)
+	}
+
+	w := request(r, http.MethodGet, "/api-keys", "", "o1")
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), created.Key) {
+		t.Errorf("list: status = %d, want 200 without the plain key: %s", w.Code, w.Body)
+	}
+
+	w = request(r, http.MethodPatch, path, `{"permissions":["read:book","read:author"]}`, "o1")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "read:author") {
+		t.Errorf("update: status = %d: %s", w.Code, w.Body)
+	}
+
+	if w := request(r, http.MethodDelete, path, "", "o1"); w.Code != http.StatusOK {
+		t.Errorf("delete: status = %d, want 200: %s", w.Code, w.Body)
+	}
+	if w := request(r, http.MethodGet, path, "", "o1"); w.Code != http.StatusNotFound {
+		t.Errorf("get after delete: status = %d, want 404: %s", w.Code, w.Body)
+	}
+}
+
+func TestAPIKeyPermissions(t *testing.T) {
+	r := newTestRouter(openTestDB(t))
+	created := createAPIKey(t, r, "o1")
+	path := "/api-keys/" + strconv.FormatUint(uint64(created.ID), 10)
+
+	tests := []struct {
+		name       string
+		permission string
+		want       int
+	}{
+		{"wildcard", "*", http.StatusBadRequest},
+		{"resource wildcard", "read:*", http.StatusBadRequest},
+		{"unknown", "read:secrets", http.StatusBadRequest},
+		{"user administration", middleware.UserAdminPermission, http.StatusBadRequest},
+		{"not held", "delete:book", http.StatusForbidden},
+		{"held", "read:author", http.StatusOK},
+		{"admin, held", middleware.AdminPermission, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := request(r, http.MethodPost, "/api-keys", `{"name":"ci","permissions":["read:book","`+tt.permission+`"]}`, "o1")
+			if w.Code != tt.want {
+				t.Errorf("create: status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+
+			w = request(r, http.MethodPatch, path, `{"permissions":["`+tt.permission+`"]}`, "o1")
+			if w.Code != tt.want {
+				t.Errorf("update: status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestAPIKeyOfAnotherOrg(t *testing.T) {
+	r := newTestRouter(openTestDB(t))
+	created := createAPIKey(t, r, "o1")
+	path := "/api-keys/" + strconv.FormatUint(uint64(created.ID), 10)
+
+	if w := request(r, http.MethodGet, "/api-keys", "", "o2"); w.Code != http.StatusOK || strings.Contains(w.Body.String(), created.Prefix) {
+		t.Errorf("list: status = %d, want 200 without the key: %s", w.Code, w.Body)
+	}
+	if w := request(r, http.MethodGet, path, "", "o2"); w.Code != http.StatusNotFound {
+		t.Errorf("get: status = %d, want 404: %s", w.Code, w.Body)
+	}
+	if w := request(r, http.MethodPatch, path, `{"name":"stolen"}`, "o2"); w.Code != http.StatusNotFound {
+		t.Errorf("update: status = %d, want 404: %s", w.Code, w.Body)
+	}
+	if w := request(r, http.MethodDelete, path, "", "o2"); w.Code != http.StatusNotFound {
+		t.Errorf("delete: status = %d, want 404: %s", w.Code, w.Body)
+	}
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	db := openTestDB(t)
+	created := createAPIKey(t, newTestRouter(db), "o1")
+
+	r := gin.New()
+	r.GET("/claims", middleware.APIKeyAuth(db, nil), func(c *gin.Context) {
+		claims, customClaims, err := middleware.GetClaims(c)
+		if err != nil {
+			middleware.AbortWithError(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"sub": claims.RegisteredClaims.Subject, "org": customClaims.OrgID})
+	})
+
+	get := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/claims", nil)
+		req.Header.Set(middleware.APIKeyHeaderName, key)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get(created.Key)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"org":"o1"`) {
+		t.Errorf("valid key: status = %d, want 200 in o1: %s", w.Code, w.Body)
+	}
+
+	var apiKey models.APIKey
+	if err := db.First(&apiKey, created.ID).Error; err != nil || apiKey.LastUsedAt == nil {
+		t.Errorf("last used at = %v, %v, want it recorded", apiKey.LastUsedAt, err)
+	}
+
+	if w := get(created.Key + "x"); w.Code != http.StatusUnauthorized {
+		t.Errorf("unknown key: status = %d, want 401: %s", w.Code, w.Body)
+	}
+}
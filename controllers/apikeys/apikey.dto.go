@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/fahmiyonda007/go-gin-gorm/models"
+)
+
+type CreateAPIKeyInput struct {
+	Name        string     `json:"name" binding:"required"`
+	Permissions []string   `json:"permissions" binding:"required,min=1"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+}
+
+type UpdateAPIKeyInput struct {
+	Name        string     `json:"name"`
+	Permissions []string   `json:"permissions"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+}
+
+type APIKeyOutput struct {
+	ID          uint       `json:"id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	Permissions []string   `json:"permissions"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+	LastUsedAt  *time.Time `json:"lastUsedAt"`
+}
+
+// newAPIKeyOutput leaves out the hash and bookkeeping of apiKey.
+func newAPIKeyOutput(apiKey models.APIKey) APIKeyOutput {
+	return APIKeyOutput{
+		ID:          apiKey.ID,
+		Name:        apiKey.Name,
+		Prefix:      apiKey.Prefix,
+		Permissions: apiKey.Permissions,
+		ExpiresAt:   apiKey.ExpiresAt,
+		LastUsedAt:  apiKey.LastUsedAt,
+	}
+}
+
+// CreatedAPIKeyOutput is the only time the plain key is returned.
+type CreatedAPIKeyOutput struct {
+	APIKeyOutput
+	Key string `json:"key"`
+}
@@ -0,0 +1,123 @@
+// controllers/audit.go
+
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	metadata "github.com/fahmiyonda007/go-gin-gorm/controllers/pagination"
+	"github.com/fahmiyonda007/go-gin-gorm/middleware"
+	"github.com/fahmiyonda007/go-gin-gorm/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AuditController serves the audit events route.
+type AuditController struct {
+	db         *gorm.DB
+	authorizer *middleware.Authorizer
+}
+
+// NewAuditController creates an AuditController on db. It asks
+// authorizer who may see the events recorded without an organization.
+func NewAuditController(db *gorm.DB, authorizer *middleware.Authorizer) *AuditController {
+	return &AuditController{db: db, authorizer: authorizer}
+}
+
+//	@BasePath	/api/v1
+//
+// AuditEvents godoc
+//
+//	@Summary	get audit events
+//	@Schemes
+//	@Description	get login attempts, rejected credentials and permission denials, newest first. Admins see the events of their organization. Logins and rejected credentials are recorded before any organization is known, only holders of manage:users see them
+//	@Tags			Audit
+//	@Param			sub		query	string	false	"subject, or username for login attempts"
+//	@Param			type	query	string	false	"login_succeeded, login_failed, token_rejected or permission_denied"
+//	@Param			from	query	string	false	"RFC 3339 time, inclusive"
+//	@Param			to		query	string	false	"RFC 3339 time, exclusive"
+//	@Param			page	query	int		false	"page"
+//	@Param			length	query	int		false	"length"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		models.AuditEvent
+//	@Failure		400	{object}	handler.JSONResult
+//	@Failure		401	{object}	handler.JSONResult
+//	@Router			/admin/audit-events [get]
+//	@Security		BearerAuth
+func (ctl *AuditController) AuditEvents(c *gin.Context) {
+	//url.domain?page=1&length=10
+	page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+	length, _ := strconv.ParseInt(c.DefaultQuery("length", "10"), 10, 64)
+
+	if page < 1 || length < 1 || length > 50 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": "page must be at least 1 and length between 1 and 50",
+		})
+		return
+	}
+
+	// Login attempts and rejected credentials are recorded before the
+	// caller's organization is known and carry none. They may come from
+	// any organization, so only holders of manage:users, who operate the
+	// whole service, see them next to the events of their own.
+	orgs := []string{middleware.OrgID(c)}
+	if ctl.authorizer.HasPermission(c, middleware.UserAdminPermission) {
+		orgs = append(orgs, "")
+	}
+	query := ctl.db.WithContext(c.Request.Context()).Model(&models.AuditEvent{}).
+		Where("org_id IN ?", orgs)
+	if sub := c.Query("sub"); sub != "" {
+		query = query.Where("sub = ?", sub)
+	}
+	if eventType := c.Query("type"); eventType != "" {
+		query = query.Where("type = ?", eventType)
+	}
+	for param, condition := range map[string]string{"from": "created_at >= ?", "to": "created_at < ?"} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":  http.StatusBadRequest,
+				"error": param + " must be an RFC 3339 time",
+			})
+			return
+		}
+		query = query.Where(condition, t)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		abortWithInternalError(c, err)
+		return
+	}
+
+	var events []models.AuditEvent
+	if err := query.Order("created_at desc, id desc").Limit(metadata.Limit(int(length))).Offset(metadata.Offset(int(page), int(length))).Find(&events).Error; err != nil {
+		abortWithInternalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"metadata": metadata.CalculateMetadata(int(count), int(page), int(length)),
+		"data":     events,
+	})
+}
+
+// abortWithInternalError logs err and answers without its details, which
+// may describe the database.
+func abortWithInternalError(c *gin.Context, err error) {
+	log.Printf("Error reading the audit events: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"code":  http.StatusInternalServerError,
+		"error": "Internal server error",
+	})
+}
@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fahmiyonda007/go-gin-gorm/middleware"
+	"github.com/fahmiyonda007/go-gin-gorm/migrations"
+	"github.com/fahmiyonda007/go-gin-gorm/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := models.Open(models.DatabaseConfig{Driver: "sqlite", Name: ":memory:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func TestAuditEventsScoping(t *testing.T) {
+	db := openTestDB(t)
+	ctl := NewAuditController(db, middleware.NewAuthorizer(middleware.AuthorizerConfig{}))
+
+	events := []models.AuditEvent{
+		{Type: models.AuditTokenRejected, Reason: "token is expired"},
+		{Type: models.AuditLoginFailed, Sub: "alice@example.com"},
+		{Type: models.AuditPermissionDenied, Sub: "auth0|alice", OrgID: "o1"},
+		{Type: models.AuditPermissionDenied, Sub: "auth0|bob", OrgID: "o2"},
+	}
+	if err := db.Create(&events).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		org         string
+		permissions []string
+		want        map[string]bool
+	}{
+		{"admin of o1", "o1", []string{middleware.AdminPermission}, map[string]bool{"auth0|alice": true}},
+		{"admin of o2", "o2", []string{middleware.AdminPermission}, map[string]bool{"auth0|bob": true}},
+		{"operator in o1", "o1", []string{middleware.AdminPermission, middleware.UserAdminPermission}, map[string]bool{"token_rejected": true, "login_failed": true, "auth0|alice": true}},
+		{"admin without organization", "", []string{middleware.AdminPermission}, map[string]bool{"token_rejected": true, "login_failed": true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/audit-events", func(c *gin.Context) {
+				middleware.SetClaims(c, "auth0|admin", middleware.CustomClaims{OrgID: tt.org, Permissions: tt.permissions})
+			}, ctl.AuditEvents)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit-events", nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+			}
+
+			var body struct {
+				Data []models.AuditEvent `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+
+			got := map[string]bool{}
+			for _, event := range body.Data {
+				if event.Type == models.AuditPermissionDenied {
+					got[event.Sub] = true
+				} else {
+					got[event.Type] = true
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("events = %v, want %v", got, tt.want)
+			}
+			for key := range tt.want {
+				if !got[key] {
+					t.Errorf("events = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestAuditEventsDatabaseError(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.Exec("DROP TABLE audit_events").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.GET("/audit-events", NewAuditController(db, middleware.NewAuthorizer(middleware.AuthorizerConfig{})).AuditEvents)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit-events", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500: %s", w.Code, w.Body)
+	}
+}
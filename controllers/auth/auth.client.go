@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fahmiyonda007/go-gin-gorm/client"
+)
+
+// tokenTimeout bounds every call to the Auth0 authentication API.
+const tokenTimeout = 10 * time.Second
+
+// tokenClient calls the Auth0 authentication API on behalf of the
+// application configured by the AUTH0_* settings.
+type tokenClient struct {
+	baseURL      string
+	clientID     string
+	clientSecret string
+	audience     string
+	httpClient   *http.Client
+}
+
+// newTokenClient reads the AUTH0_* settings, see client.BaseURL for
+// AUTH0_DOMAIN.
+func newTokenClient() *tokenClient {
+	return &tokenClient{
+		baseURL:      client.BaseURL(os.Getenv("AUTH0_DOMAIN")),
+		clientID:     os.Getenv("AUTH0_CLIENTID"),
+		clientSecret: os.Getenv("AUTH0_CLIENTSECRET"),
+		audience:     os.Getenv("AUTH0_AUDIENCE"),
+		httpClient:   &http.Client{Timeout: tokenTimeout},
+	}
+}
+
+func (t *tokenClient) url(path string) string {
+	return t.baseURL + path
+}
+
+func (t *tokenClient) passwordGrant(ctx context.Context, username string, password string) (*TokenOutput, error) {
+	return t.token(ctx, url.Values{
+		"grant_type":    {"password"},
+		"username":      {username},
+		"password":      {password},
+		"client_id":     {t.clientID},
+		"client_secret": {t.clientSecret},
+		"audience":      {t.audience},
+		"scope":         {"openid offline_access"},
+	})
+}
+
+func (t *tokenClient) refreshGrant(ctx context.Context, refreshToken string) (*TokenOutput, error) {
+	return t.token(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+		"client_id":     {t.clientID},
+		"client_secret": {t.clientSecret},
+	})
+}
+
+func (t *tokenClient) clientCredentialsGrant(ctx context.Context, clientID string, clientSecret string) (*TokenOutput, error) {
+	return t.token(ctx, url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {clientID},
+		"client_secret": {clientSecret},
+		"audience":      {t.audience},
+	})
+}
+
+func (t *tokenClient) authorizationCodeGrant(ctx context.Context, code string, verifier string, redirectURI string) (*TokenOutput, error) {
+	return t.token(ctx, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"code_verifier": {verifier},
+		"redirect_uri":  {redirectURI},
+		"client_id":     {t.clientID},
+		"client_secret": {t.clientSecret},
+	})
+}
+
+func (t *tokenClient) revoke(ctx context.Context, refreshToken string) error {
+	return t.post(ctx, "/oauth/revoke", url.Values{
+		"token":         {refreshToken},
+		"client_id":     {t.clientID},
+		"client_secret": {t.clientSecret},
+	}, nil)
+}
+
+func (t *tokenClient) token(ctx context.Context, form url.Values) (*TokenOutput, error) {
+	var output TokenOutput
+	if err := t.post(ctx, "/oauth/token", form, &output); err != nil {
+		return nil, err
+	}
+	return &output, nil
+}
+
+// post sends form to the endpoint at path and decodes a successful JSON
+// answer into out, which may be nil. When Auth0 answers with an error
+// status the decoded *Auth0Error is returned.
+func (t *tokenClient) post(ctx context.Context, path string, form url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url(path), strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+
+	res, err := t.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		auth0Err := &Auth0Error{StatusCode: res.StatusCode}
+		if err := json.Unmarshal(body, auth0Err); err != nil || auth0Err.Code == "" {
+			auth0Err.Code = "server_error"
+		}
+		return auth0Err
+	}
+
+	if out == nil || len(body) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("could not decode the Auth0 response: %w", err)
+	}
+
+	return nil
+}
+
+// Auth0Error is the error body returned by the Auth0 authentication API.
+type Auth0Error struct {
+	StatusCode  int    `json:"-"`
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *Auth0Error) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Description)
+}
+
+// Status maps the Auth0 error code to the status we answer with, so a
+// client can tell bad credentials from a missing second factor or from
+// being throttled.
+func (e *Auth0Error) Status() int {
+	switch e.Code {
+	case "invalid_grant", "invalid_client":
+		return http.StatusUnauthorized
+	case "mfa_required", "unauthorized_client", "access_denied", "consent_required":
+		return http.StatusForbidden
+	case "too_many_attempts":
+		return http.StatusTooManyRequests
+	case "invalid_request", "unsupported_grant_type", "invalid_scope":
+		return http.StatusBadRequest
+	}
+
+	if e.StatusCode >= http.StatusInternalServerError {
+		return http.StatusBadGateway
+	}
+	return e.StatusCode
+}
+
+// errorOutput converts any error of the token client into the body and
+// status we answer with.
+func errorOutput(err error) ErrorOutput {
+	var auth0Err *Auth0Error
+	switch {
+	case errors.As(err, &auth0Err):
+		return ErrorOutput{
+			Code:    auth0Err.Status(),
+			Error:   auth0Err.Code,
+			Message: auth0Err.Description,
+		}
+	case errors.Is(err, context.DeadlineExceeded), os.IsTimeout(err):
+		return ErrorOutput{
+			Code:    http.StatusGatewayTimeout,
+			Error:   "upstream_timeout",
+			Message: "Auth0 did not answer in time",
+		}
+	default:
+		return ErrorOutput{
+			Code:    http.StatusBadGateway,
+			Error:   "upstream_unavailable",
+			Message: err.Error(),
+		}
+	}
+}
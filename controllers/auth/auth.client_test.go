@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestTokenClientPostErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantStatus int
+		wantError  string
+	}{
+		{"wrong password", http.StatusForbidden, `{"error":"invalid_grant","error_description":"Wrong email or password."}`, http.StatusUnauthorized, "invalid_grant"},
+		{"second factor", http.StatusForbidden, `{"error":"mfa_required","error_description":"Multifactor authentication required"}`, http.StatusForbidden, "mfa_required"},
+		{"throttled", http.StatusTooManyRequests, `{"error":"too_many_attempts","error_description":"Your account has been blocked."}`, http.StatusTooManyRequests, "too_many_attempts"},
+		{"bad request", http.StatusBadRequest, `{"error":"invalid_request","error_description":"Missing username."}`, http.StatusBadRequest, "invalid_request"},
+		{"outage", http.StatusServiceUnavailable, `<html>Service Unavailable</html>`, http.StatusBadGateway, "server_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newAuth0StandIn(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			})
+
+			err := newTokenClient().post(context.Background(), "/oauth/token", url.Values{}, nil)
+			if err == nil {
+				t.Fatal("post succeeded, want an error")
+			}
+
+			output := errorOutput(err)
+			if output.Code != tt.wantStatus || output.Error != tt.wantError {
+				t.Errorf("errorOutput = %d %s, want %d %s", output.Code, output.Error, tt.wantStatus, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestTokenClientPostTimeout(t *testing.T) {
+	release := make(chan struct{})
+	newAuth0StandIn(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	defer close(release)
+
+	client := newTokenClient()
+	client.httpClient = &http.Client{Timeout: 50 * time.Millisecond}
+
+	err := client.post(context.Background(), "/oauth/token", url.Values{}, nil)
+	if err == nil {
+		t.Fatal("post succeeded, want a timeout")
+	}
+
+	if output := errorOutput(err); output.Code != http.StatusGatewayTimeout || output.Error != "upstream_timeout" {
+		t.Errorf("errorOutput = %d %s, want 504 upstream_timeout", output.Code, output.Error)
+	}
+}
+
+func TestTokenClientPostDecodesAnswer(t *testing.T) {
+	newAuth0StandIn(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"access-token","expires_in":86400,"token_type":"Bearer"}`))
+	})
+
+	output, err := newTokenClient().token(context.Background(), url.Values{"grant_type": {"password"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.AccessToken != "access-token" || output.ExpiresIn != 86400 {
+		t.Errorf("output = %+v", output)
+	}
+}
+
+func TestAuth0ErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  Auth0Error
+		want int
+	}{
+		{Auth0Error{StatusCode: http.StatusForbidden, Code: "invalid_grant"}, http.StatusUnauthorized},
+		{Auth0Error{StatusCode: http.StatusUnauthorized, Code: "invalid_client"}, http.StatusUnauthorized},
+		{Auth0Error{StatusCode: http.StatusForbidden, Code: "mfa_required"}, http.StatusForbidden},
+		{Auth0Error{StatusCode: http.StatusTooManyRequests, Code: "too_many_attempts"}, http.StatusTooManyRequests},
+		{Auth0Error{StatusCode: http.StatusBadRequest, Code: "unsupported_grant_type"}, http.StatusBadRequest},
+		{Auth0Error{StatusCode: http.StatusInternalServerError, Code: "server_error"}, http.StatusBadGateway},
+		{Auth0Error{StatusCode: http.StatusNotFound, Code: "not_found"}, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		if got := tt.err.Status(); got != tt.want {
+			t.Errorf("Auth0Error{%d, %s}.Status() = %d, want %d", tt.err.StatusCode, tt.err.Code, got, tt.want)
+		}
+	}
+}
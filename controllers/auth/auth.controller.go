@@ -1,19 +1,17 @@
-// controllers/books.go
+// controllers/auth.go
 
 package controllers
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/fahmiyonda007/go-gin-gorm/middleware"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
-	"github.com/joho/godotenv"
 )
 
 //	@BasePath	/api/v1
@@ -22,102 +20,392 @@ import (
 //
 //	@Summary	auth0
 //	@Schemes
-//	@Description	login with auth0 user
+//	@Description	login with auth0 user, in session mode the tokens are set as cookies and only {"data": true} is returned
 //	@Tags			Authentication
 //	@Param			login	body	LoginInput	true	"Login"
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{object}	LoginOutput
+//	@Success		200	{object}	TokenOutput
+//	@Failure		401	{object}	ErrorOutput
+//	@Failure		403	{object}	ErrorOutput
+//	@Failure		429	{object}	ErrorOutput
 //	@Router			/login [post]
-func Login(c *gin.Context) {
-	if err := godotenv.Load(); err != nil {
-		log.Fatalf("Error loading the .env file: %v", err)
+func Login(sessions *middleware.SessionCodec) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Validate input
+		var input LoginInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			abortWithBadRequest(c, err)
+			return
+		}
+
+		output, err := newTokenClient().passwordGrant(c.Request.Context(), input.Username, input.Password)
+		if err != nil {
+			abortWithTokenError(c, err)
+			return
+		}
+
+		respondWithTokens(c, sessions, output)
 	}
+}
 
-	domain := os.Getenv("AUTH0_DOMAIN")
-	audience := os.Getenv("AUTH0_AUDIENCE")
-	clientId := os.Getenv("AUTH0_CLIENTID")
-	clientSecret := os.Getenv("AUTH0_CLIENTSECRET")
+//	@BasePath	/api/v1
 
-	url := "https://" + domain + "/oauth/token"
+// Refresh godoc
+//
+//	@Summary	refresh an access token
+//	@Schemes
+//	@Description	exchange a refresh token obtained from login for a new access token, in session mode the refresh token is read from its cookie
+//	@Tags			Authentication
+//	@Param			input	body	RefreshInput	false	"Input"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	TokenOutput
+//	@Failure		401	{object}	ErrorOutput
+//	@Router			/token/refresh [post]
+func Refresh(sessions *middleware.SessionCodec) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := sessionRefreshToken(c, sessions)
+		if !ok {
+			// Validate input
+			var input RefreshInput
+			if err := c.ShouldBindJSON(&input); err != nil {
+				abortWithBadRequest(c, err)
+				return
+			}
+			token = input.RefreshToken
+		}
 
+		output, err := newTokenClient().refreshGrant(c.Request.Context(), token)
+		if err != nil {
+			abortWithTokenError(c, err)
+			return
+		}
+
+		respondWithTokens(c, sessions, output)
+	}
+}
+
+//	@BasePath	/api/v1
+
+// Logout godoc
+//
+//	@Summary	revoke a refresh token
+//	@Schemes
+//	@Description	revoke the refresh token obtained from login so it can no longer be used, in session mode the refresh token is read from its cookie
+//	@Tags			Authentication
+//	@Param			input	body	LogoutInput	false	"Input"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	handler.JSONResult
+//	@Failure		401	{object}	ErrorOutput
+//	@Router			/logout [post]
+func Logout(sessions *middleware.SessionCodec) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := sessionRefreshToken(c, sessions)
+		if !ok {
+			// Validate input
+			var input LogoutInput
+			if err := c.ShouldBindJSON(&input); err != nil {
+				abortWithBadRequest(c, err)
+				return
+			}
+			token = input.RefreshToken
+		}
+
+		if err := newTokenClient().revoke(c.Request.Context(), token); err != nil {
+			abortWithTokenError(c, err)
+			return
+		}
+
+		if sessions != nil {
+			sessions.ClearCookies(c.Writer)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": true})
+	}
+}
+
+//	@BasePath	/api/v1
+
+// ClientToken godoc
+//
+//	@Summary	machine-to-machine login
+//	@Schemes
+//	@Description	get an access token for a service account with the client credentials grant
+//	@Tags			Authentication
+//	@Param			input	body	ClientTokenInput	true	"Input"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	TokenOutput
+//	@Failure		401	{object}	ErrorOutput
+//	@Router			/token/client [post]
+func ClientToken(c *gin.Context) {
 	// Validate input
-	var input LoginInput
+	var input ClientTokenInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":  http.StatusBadRequest,
-			"error": err.Error(),
-		})
+		abortWithBadRequest(c, err)
+		return
+	}
+
+	output, err := newTokenClient().clientCredentialsGrant(c.Request.Context(), input.ClientID, input.ClientSecret)
+	if err != nil {
+		abortWithTokenError(c, err)
 		return
 	}
 
-	payload := strings.NewReader("grant_type=password&username=" + input.Username + "&password=" + input.Password + "&client_id=" + clientId + "&client_secret=" + clientSecret + "&audience=" + audience)
+	c.JSON(http.StatusOK, output)
+}
+
+// stateTTL bounds how long a user may take to log in at Auth0.
+const stateTTL = 10 * time.Minute
 
-	req, _ := http.NewRequest("POST", url, payload)
+//	@BasePath	/api/v1
 
-	req.Header.Add("content-type", "application/x-www-form-urlencoded")
+// Authorize godoc
+//
+//	@Summary	browser login
+//	@Schemes
+//	@Description	redirect the browser to the Auth0 login page using the authorization code flow with PKCE
+//	@Tags			Authentication
+//	@Success		302
+//	@Router			/auth/authorize [get]
+func Authorize(store StateStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		state, err := randomString(32)
+		if err != nil {
+			abortWithInternalError(c, err)
+			return
+		}
 
-	res, _ := http.DefaultClient.Do(req)
+		verifier, err := randomString(32)
+		if err != nil {
+			abortWithInternalError(c, err)
+			return
+		}
 
-	defer res.Body.Close()
-	body, _ := io.ReadAll(res.Body)
+		if err := store.Save(state, verifier, stateTTL); err != nil {
+			abortWithInternalError(c, err)
+			return
+		}
+		setStateCookie(c.Writer, state, stateTTL)
 
-	// fmt.Println(res)
-	// fmt.Println(string(body))
+		client := newTokenClient()
+		query := url.Values{
+			"response_type":         {"code"},
+			"client_id":             {client.clientID},
+			"redirect_uri":          {callbackURL()},
+			"audience":              {client.audience},
+			"scope":                 {"openid profile email offline_access"},
+			"state":                 {state},
+			"code_challenge":        {codeChallenge(verifier)},
+			"code_challenge_method": {"S256"},
+		}
 
-	var m map[string]interface{}
-	if err := json.Unmarshal(body, &m); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"data": err.Error})
-		return
+		c.Redirect(http.StatusFound, client.url("/authorize")+"?"+query.Encode())
 	}
+}
 
-	c.JSON(http.StatusOK, m)
+//	@BasePath	/api/v1
+
+// Callback godoc
+//
+//	@Summary	browser login callback
+//	@Schemes
+//	@Description	exchange the authorization code Auth0 sent back for tokens, or set them as a session cookie in session mode
+//	@Tags			Authentication
+//	@Param			code	query	string	true	"code"
+//	@Param			state	query	string	true	"state"
+//	@Produce		json
+//	@Success		200	{object}	TokenOutput
+//	@Failure		400	{object}	ErrorOutput
+//	@Failure		401	{object}	ErrorOutput
+//	@Router			/auth/callback [get]
+func Callback(store StateStore, sessions *middleware.SessionCodec) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authErr := c.Query("error"); authErr != "" {
+			c.JSON(http.StatusUnauthorized, ErrorOutput{
+				Code:    http.StatusUnauthorized,
+				Error:   authErr,
+				Message: c.Query("error_description"),
+			})
+			return
+		}
+
+		// A state is only good in the browser that asked for it, otherwise
+		// anyone could log a victim into their own account by sending
+		// them a callback URL.
+		if !stateCookieMatches(c.Request, c.Query("state")) {
+			c.JSON(http.StatusBadRequest, ErrorOutput{
+				Code:    http.StatusBadRequest,
+				Error:   "invalid_request",
+				Message: "State does not belong to this browser",
+			})
+			return
+		}
+		clearStateCookie(c.Writer)
+
+		verifier, ok, err := store.Take(c.Query("state"))
+		if err != nil {
+			abortWithInternalError(c, err)
+			return
+		}
+
+		if !ok || c.Query("code") == "" {
+			c.JSON(http.StatusBadRequest, ErrorOutput{
+				Code:    http.StatusBadRequest,
+				Error:   "invalid_request",
+				Message: "Invalid or expired state",
+			})
+			return
+		}
+
+		output, err := newTokenClient().authorizationCodeGrant(c.Request.Context(), c.Query("code"), verifier, callbackURL())
+		if err != nil {
+			abortWithTokenError(c, err)
+			return
+		}
+
+		if sessions == nil {
+			c.JSON(http.StatusOK, output)
+			return
+		}
+
+		if err := setSession(c, sessions, output); err != nil {
+			abortWithInternalError(c, err)
+			return
+		}
+
+		if redirect := os.Getenv("AUTH_POST_LOGIN_URL"); redirect != "" {
+			c.Redirect(http.StatusFound, redirect)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": true})
+	}
+}
+
+//	@BasePath	/api/v1
+
+// Me godoc
+//
+//	@Summary	current user
+//	@Schemes
+//	@Description	get the identity, scopes and permissions of the caller so a UI can hide what the user can't do
+//	@Tags			Authentication
+//	@Produce		json
+//	@Success		200	{object}	MeOutput
+//	@Failure		401	{object}	handler.JSONResult
+//	@Router			/me [get]
+//	@Security		BearerAuth
+func Me(sessions *middleware.SessionCodec, userInfo *UserInfoCache, authorizer *middleware.Authorizer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, customClaims, err := middleware.GetClaims(c)
+		if err != nil {
+			middleware.AbortWithError(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		output := MeOutput{
+			Sub:         claims.RegisteredClaims.Subject,
+			Scopes:      strings.Fields(customClaims.Scope),
+			Permissions: authorizer.EffectivePermissions(customClaims),
+		}
+
+		// The profile is a nicety, tokens without the openid scope can't
+		// read it and the identity above is still accurate.
+		if userInfo != nil {
+			info, err := readUserInfo(c, sessions, userInfo, claims.RegisteredClaims.Issuer, output.Sub)
+			if err != nil {
+				log.Printf("Error reading the profile of %s: %v", output.Sub, err)
+			} else {
+				output.Email = info.Email
+				output.Name = info.Name
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": output})
+	}
+}
+
+func readUserInfo(c *gin.Context, sessions *middleware.SessionCodec, userInfo *UserInfoCache, issuer string, sub string) (*UserInfo, error) {
+	token, err := middleware.RawToken(c.Request, sessions)
+	if err != nil {
+		return nil, err
+	}
+
+	return userInfo.Get(c.Request.Context(), issuer, sub, token)
 }
 
-func Authenticate(c *gin.Context) (*jwt.Token, error) {
-	if err := godotenv.Load(); err != nil {
-		log.Fatalf("Error loading the .env file: %v", err)
+// callbackURL is where Auth0 sends the browser back to after login. It
+// must be listed in the Allowed Callback URLs of the Auth0 application.
+func callbackURL() string {
+	if callback := os.Getenv("AUTH0_CALLBACK_URL"); callback != "" {
+		return callback
 	}
+	return os.Getenv("APP_HOST") + ":" + os.Getenv("APP_PORT") + "/api/v1/auth/callback"
+}
 
-	tokenString := c.GetHeader("Authorization")
-	if tokenString == "" {
-		return nil, fmt.Errorf("Authorization header missing")
+// respondWithTokens answers with the tokens Auth0 issued. In session mode
+// they go into cookies instead and the body only reports success.
+func respondWithTokens(c *gin.Context, sessions *middleware.SessionCodec, output *TokenOutput) {
+	if sessions == nil {
+		c.JSON(http.StatusOK, output)
+		return
 	}
 
-	tokenString = tokenString[len("Bearer "):]
+	if err := setSession(c, sessions, output); err != nil {
+		abortWithInternalError(c, err)
+		return
+	}
 
-	// token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-	// 	// Replace with your Auth0 secret
-	// 	return []byte(os.Getenv("AUTH0_SECRET")), nil
-	// })
-	// if err != nil {
-	// 	return nil, fmt.Errorf(err.Error())
-	// }
+	c.JSON(http.StatusOK, gin.H{"data": true})
+}
 
-	token, _, err := new(jwt.Parser).ParseUnverified(tokenString, jwt.MapClaims{})
-	if err != nil {
-		return nil, fmt.Errorf(err.Error())
+// setSession stores the access token in the session cookie and the
+// refresh token, when Auth0 issued one, in the refresh cookie.
+func setSession(c *gin.Context, sessions *middleware.SessionCodec, output *TokenOutput) error {
+	if err := sessions.SetCookies(c.Writer, output.AccessToken, output.ExpiresIn); err != nil {
+		return err
 	}
 
-	return token, nil
+	if output.RefreshToken == "" {
+		return nil
+	}
+	return sessions.SetRefreshCookie(c.Writer, output.RefreshToken)
 }
 
-func HasPermission(token *jwt.Token, permission string) bool {
-	// claims, ok := token.Claims.(jwt.MapClaims)
-	claims := token.Claims.(jwt.MapClaims)
-	// if !ok || !token.Valid {
-	// 	return false
-	// }
-	permissions, exists := claims["permissions"].([]interface{})
-	if !exists {
-		return false
+// sessionRefreshToken reads the refresh token from its cookie in session
+// mode. ok is false when there is none and the body must carry it.
+func sessionRefreshToken(c *gin.Context, sessions *middleware.SessionCodec) (token string, ok bool) {
+	if sessions == nil {
+		return "", false
 	}
 
-	for _, p := range permissions {
-		if p == permission {
-			return true
-		}
+	token, err := sessions.RefreshToken(c.Request)
+	if err != nil {
+		return "", false
 	}
+	return token, true
+}
+
+func abortWithTokenError(c *gin.Context, err error) {
+	output := errorOutput(err)
+	c.JSON(output.Code, output)
+}
+
+func abortWithBadRequest(c *gin.Context, err error) {
+	c.JSON(http.StatusBadRequest, ErrorOutput{
+		Code:    http.StatusBadRequest,
+		Error:   "invalid_request",
+		Message: err.Error(),
+	})
+}
 
-	return false
+func abortWithInternalError(c *gin.Context, err error) {
+	c.JSON(http.StatusInternalServerError, ErrorOutput{
+		Code:    http.StatusInternalServerError,
+		Error:   "server_error",
+		Message: err.Error(),
+	})
 }
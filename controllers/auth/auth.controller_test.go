@@ -0,0 +1,325 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/fahmiyonda007/go-gin-gorm/middleware"
+	"github.com/fahmiyonda007/go-gin-gorm/policy"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newAuth0StandIn points AUTH0_DOMAIN at a server answering the Auth0
+// authentication API with handler.
+func newAuth0StandIn(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	t.Setenv("AUTH0_DOMAIN", server.URL)
+	t.Setenv("AUTH0_CLIENTID", "client-id")
+	t.Setenv("AUTH0_CLIENTSECRET", "client-secret")
+	t.Setenv("AUTH0_AUDIENCE", "https://books.example.com")
+
+	return server
+}
+
+// writeAuth0Error answers like Auth0 does when it refuses a request.
+func writeAuth0Error(w http.ResponseWriter, status int, code string, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
+
+func postJSON(h gin.HandlerFunc, body string) *httptest.ResponseRecorder {
+	r := gin.New()
+	r.POST("/", h)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	return w
+}
+
+func decodeError(t *testing.T, w *httptest.ResponseRecorder) ErrorOutput {
+	t.Helper()
+
+	var output ErrorOutput
+	if err := json.Unmarshal(w.Body.Bytes(), &output); err != nil {
+		t.Fatalf("could not decode %q: %v", w.Body, err)
+	}
+	return output
+}
+
+func TestRefresh(t *testing.T) {
+	var form url.Values
+	newAuth0StandIn(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/oauth/token" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = r.ParseForm()
+		form = r.PostForm
+
+		if form.Get("refresh_token") != "good-refresh-token" {
+			writeAuth0Error(w, http.StatusForbidden, "invalid_grant", "Unknown or invalid refresh token.")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(TokenOutput{AccessToken: "new-access-token", ExpiresIn: 3600, TokenType: "Bearer"})
+	})
+
+	t.Run("exchanges the refresh token", func(t *testing.T) {
+		w := postJSON(Refresh(nil), `{"refresh_token":"good-refresh-token"}`)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+		}
+		if form.Get("grant_type") != "refresh_token" || form.Get("client_secret") != "client-secret" {
+			t.Errorf("token endpoint got %v", form)
+		}
+
+		var output TokenOutput
+		if err := json.Unmarshal(w.Body.Bytes(), &output); err != nil {
+			t.Fatal(err)
+		}
+		if output.AccessToken != "new-access-token" {
+			t.Errorf("access token = %q, want new-access-token", output.AccessToken)
+		}
+	})
+
+	t.Run("invalid refresh token", func(t *testing.T) {
+		w := postJSON(Refresh(nil), `{"refresh_token":"revoked"}`)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("status = %d, want 401: %s", w.Code, w.Body)
+		}
+		if output := decodeError(t, w); output.Error != "invalid_grant" {
+			t.Errorf("error = %q, want invalid_grant", output.Error)
+		}
+	})
+
+	t.Run("missing refresh token", func(t *testing.T) {
+		w := postJSON(Refresh(nil), `{}`)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400: %s", w.Code, w.Body)
+		}
+	})
+
+	t.Run("session mode keeps the tokens out of the body", func(t *testing.T) {
+		sessions, err := middleware.NewSessionCodec("secret")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r := gin.New()
+		r.POST("/", Refresh(sessions))
+
+		cookie := httptest.NewRecorder()
+		if err := sessions.SetRefreshCookie(cookie, "good-refresh-token"); err != nil {
+			t.Fatal(err)
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.AddCookie(cookie.Result().Cookies()[0])
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+		}
+		if strings.Contains(w.Body.String(), "new-access-token") {
+			t.Errorf("body exposes the access token: %s", w.Body)
+		}
+
+		var session *http.Cookie
+		for _, c := range w.Result().Cookies() {
+			if c.Name == middleware.SessionCookieName {
+				session = c
+			}
+		}
+		if session == nil || !session.HttpOnly {
+			t.Fatalf("cookies = %v, want an HttpOnly session cookie", w.Result().Cookies())
+		}
+		if token, err := sessions.Open(session.Value); err != nil || token != "new-access-token" {
+			t.Errorf("session holds %q, %v, want new-access-token", token, err)
+		}
+	})
+}
+
+func TestLogout(t *testing.T) {
+	var revoked []string
+	newAuth0StandIn(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/oauth/revoke" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = r.ParseForm()
+
+		if r.PostForm.Get("client_secret") != "client-secret" {
+			writeAuth0Error(w, http.StatusUnauthorized, "invalid_client", "Client authentication failed.")
+			return
+		}
+		revoked = append(revoked, r.PostForm.Get("token"))
+	})
+
+	t.Run("revokes the refresh token", func(t *testing.T) {
+		w := postJSON(Logout(nil), `{"refresh_token":"refresh-token"}`)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+		}
+		if len(revoked) != 1 || revoked[0] != "refresh-token" {
+			t.Errorf("revoked = %v, want [refresh-token]", revoked)
+		}
+	})
+
+	t.Run("clears the session cookies", func(t *testing.T) {
+		sessions, err := middleware.NewSessionCodec("secret")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := postJSON(Logout(sessions), `{"refresh_token":"refresh-token"}`)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+		}
+		if cookies := w.Header().Values("Set-Cookie"); len(cookies) != 3 || !strings.Contains(cookies[0], "Max-Age=0") {
+			t.Errorf("cookies = %v, want all cleared", cookies)
+		}
+	})
+
+	t.Run("client refused", func(t *testing.T) {
+		t.Setenv("AUTH0_CLIENTSECRET", "wrong")
+
+		w := postJSON(Logout(nil), `{"refresh_token":"refresh-token"}`)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("status = %d, want 401: %s", w.Code, w.Body)
+		}
+		if output := decodeError(t, w); output.Error != "invalid_client" {
+			t.Errorf("error = %q, want invalid_client", output.Error)
+		}
+	})
+
+	t.Run("missing refresh token", func(t *testing.T) {
+		w := postJSON(Logout(nil), `{}`)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400: %s", w.Code, w.Body)
+		}
+	})
+}
+
+func TestCallbackRequiresStateCookie(t *testing.T) {
+	newAuth0StandIn(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.URL.Path != "/oauth/token" || r.PostForm.Get("code") != "code" {
+			writeAuth0Error(w, http.StatusForbidden, "invalid_grant", "Invalid authorization code.")
+			return
+		}
+		_ = json.NewEncoder(w).Encode(TokenOutput{AccessToken: "access-token", ExpiresIn: 3600, TokenType: "Bearer"})
+	})
+
+	store := NewMemoryStateStore()
+	r := gin.New()
+	r.GET("/authorize", Authorize(store))
+	r.GET("/callback", Callback(store, nil))
+
+	// authorize starts a login and returns the state and the cookie the
+	// browser got with it.
+	authorize := func() (string, *http.Cookie) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/authorize", nil))
+
+		location, err := url.Parse(w.Header().Get("Location"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cookies := w.Result().Cookies()
+		if len(cookies) != 1 || cookies[0].Name != stateCookieName || !cookies[0].HttpOnly {
+			t.Fatalf("cookies = %v, want an HttpOnly %s cookie", cookies, stateCookieName)
+		}
+		return location.Query().Get("state"), cookies[0]
+	}
+
+	callback := func(state string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/callback?code=code&state="+url.QueryEscape(state), nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("same browser", func(t *testing.T) {
+		state, cookie := authorize()
+
+		if w := callback(state, cookie); w.Code != http.StatusOK {
+			t.Errorf("status = %d, want 200: %s", w.Code, w.Body)
+		}
+	})
+
+	t.Run("state sent to another browser", func(t *testing.T) {
+		state, _ := authorize()
+
+		if w := callback(state, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400: %s", w.Code, w.Body)
+		}
+	})
+
+	t.Run("cookie of another login", func(t *testing.T) {
+		state, _ := authorize()
+		_, cookie := authorize()
+
+		if w := callback(state, cookie); w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400: %s", w.Code, w.Body)
+		}
+	})
+}
+
+func TestMeExpandsRoles(t *testing.T) {
+	engine, err := policy.New(policy.File{Roles: map[string]policy.Role{
+		"editor": {Permissions: []string{"create:book", "read:*"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorizer := middleware.NewAuthorizer(middleware.AuthorizerConfig{Policy: engine})
+
+	r := gin.New()
+	r.GET("/me", func(c *gin.Context) {
+		middleware.SetClaims(c, "auth0|editor", middleware.CustomClaims{Roles: []string{"editor"}})
+	}, Me(nil, nil, authorizer))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/me", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+
+	var body struct {
+		Data MeOutput `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(body.Data.Permissions, ","); got != "read:book,create:book,read:author" {
+		t.Errorf("permissions = %s, want read:book,create:book,read:author", got)
+	}
+}
@@ -5,6 +5,40 @@ type LoginInput struct {
 	Password string `json:"password" binding:"required"`
 }
 
-type LoginOutput struct {
-	Data string
+// ErrorOutput is the error body of every authentication endpoint. Error
+// carries the Auth0 error code, such as invalid_grant or mfa_required.
+type ErrorOutput struct {
+	Code    int    `json:"code"`
+	Error   string `json:"error"`
+	Message string `json:"message,omitempty"`
+}
+
+type RefreshInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type TokenOutput struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	ExpiresIn    int    `json:"expires_in"`
+	TokenType    string `json:"token_type"`
+}
+
+type ClientTokenInput struct {
+	ClientID     string `json:"client_id" binding:"required"`
+	ClientSecret string `json:"client_secret" binding:"required"`
+}
+
+type MeOutput struct {
+	Sub         string   `json:"sub"`
+	Email       string   `json:"email,omitempty"`
+	Name        string   `json:"name,omitempty"`
+	Scopes      []string `json:"scopes"`
+	Permissions []string `json:"permissions"`
 }
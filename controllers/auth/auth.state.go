@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// stateCookieName holds a hash of the state of the authorization request
+// the browser started, so a callback URL crafted by someone else is
+// refused.
+const stateCookieName = "auth_state"
+
+// StateStore keeps the PKCE code verifier of an authorization request
+// under its state value until the browser comes back to the callback.
+type StateStore interface {
+	// Save stores verifier under state for at most ttl.
+	Save(state string, verifier string, ttl time.Duration) error
+	// Take returns the verifier saved under state and forgets it, so a
+	// state can only be redeemed once.
+	Take(state string) (verifier string, ok bool, err error)
+}
+
+// MemoryStateStore is a StateStore for a single instance of the API.
+type MemoryStateStore struct {
+	mu      sync.Mutex
+	entries map[string]stateEntry
+}
+
+type stateEntry struct {
+	verifier  string
+	expiresAt time.Time
+}
+
+// NewMemoryStateStore creates an empty MemoryStateStore.
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{entries: map[string]stateEntry{}}
+}
+
+// Save satisfies StateStore.
+func (s *MemoryStateStore) Save(state string, verifier string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+
+	s.entries[state] = stateEntry{verifier: verifier, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+// Take satisfies StateStore.
+func (s *MemoryStateStore) Take(state string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[state]
+	if !ok {
+		return "", false, nil
+	}
+
+	delete(s.entries, state)
+	if time.Now().After(entry.expiresAt) {
+		return "", false, nil
+	}
+
+	return entry.verifier, true, nil
+}
+
+// randomString returns n random bytes encoded for use in a URL.
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// codeChallenge derives the S256 PKCE challenge of verifier.
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// setStateCookie ties state to the browser that started the login.
+func setStateCookie(w http.ResponseWriter, state string, ttl time.Duration) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookieName,
+		Value:    stateHash(state),
+		Path:     "/",
+		MaxAge:   int(ttl.Seconds()),
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// clearStateCookie removes the cookie set by setStateCookie.
+func clearStateCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookieName,
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// stateCookieMatches reports whether r comes from the browser that
+// started the authorization request identified by state.
+func stateCookieMatches(r *http.Request, state string) bool {
+	cookie, err := r.Cookie(stateCookieName)
+	if err != nil || state == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(stateHash(state))) == 1
+}
+
+func stateHash(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
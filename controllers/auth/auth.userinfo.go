@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// UserInfo is the profile Auth0 returns from /userinfo.
+type UserInfo struct {
+	Sub           string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+}
+
+// UserInfoCache remembers the /userinfo answer per user, since Auth0 rate
+// limits that endpoint and the profile rarely changes. Users are told
+// apart by issuer and subject, as several tenants may be trusted.
+type UserInfoCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[userInfoKey]userInfoEntry
+}
+
+type userInfoKey struct {
+	issuer string
+	sub    string
+}
+
+type userInfoEntry struct {
+	info      *UserInfo
+	expiresAt time.Time
+}
+
+// NewUserInfoCache keeps profiles for ttl.
+func NewUserInfoCache(ttl time.Duration) *UserInfoCache {
+	return &UserInfoCache{ttl: ttl, entries: map[userInfoKey]userInfoEntry{}}
+}
+
+// Get returns the cached profile of sub at issuer, asking Auth0 with
+// accessToken when it is missing or stale.
+func (u *UserInfoCache) Get(ctx context.Context, issuer string, sub string, accessToken string) (*UserInfo, error) {
+	key := userInfoKey{issuer: issuer, sub: sub}
+
+	u.mu.Lock()
+	entry, ok := u.entries[key]
+	u.mu.Unlock()
+
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.info, nil
+	}
+
+	info, err := newTokenClient().userInfo(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	now := time.Now()
+	for key, entry := range u.entries {
+		if now.After(entry.expiresAt) {
+			delete(u.entries, key)
+		}
+	}
+	u.entries[key] = userInfoEntry{info: info, expiresAt: now.Add(u.ttl)}
+
+	return info, nil
+}
+
+func (t *tokenClient) userInfo(ctx context.Context, accessToken string) (*UserInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.url("/userinfo"), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Authorization", "Bearer "+accessToken)
+
+	res, err := t.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("userinfo answered %s", res.Status)
+	}
+
+	var info UserInfo
+	if err := json.NewDecoder(res.Body).Decode(&info); err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
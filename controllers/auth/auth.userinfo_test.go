@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newUserInfoStandIn answers /userinfo with a profile named after the
+// bearer token, and counts the requests.
+func newUserInfoStandIn(t *testing.T) *int {
+	t.Helper()
+
+	requests := 0
+	newAuth0StandIn(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/userinfo" {
+			http.NotFound(w, r)
+			return
+		}
+		requests++
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		_ = json.NewEncoder(w).Encode(UserInfo{Sub: "auth0|alice", Name: token})
+	})
+	return &requests
+}
+
+func TestUserInfoCache(t *testing.T) {
+	requests := newUserInfoStandIn(t)
+	cache := NewUserInfoCache(time.Minute)
+	ctx := context.Background()
+
+	first, err := cache.Get(ctx, "https://tenant-a.example.com/", "auth0|alice", "token-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := cache.Get(ctx, "https://tenant-a.example.com/", "auth0|alice", "token-a2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Name != first.Name || *requests != 1 {
+		t.Errorf("second read = %q after %d requests, want the cached %q", again.Name, *requests, first.Name)
+	}
+
+	other, err := cache.Get(ctx, "https://tenant-b.example.com/", "auth0|alice", "token-b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Name != "token-b" {
+		t.Errorf("profile at another issuer = %q, want token-b rather than the cached one", other.Name)
+	}
+}
+
+func TestUserInfoCacheSweepsExpiredEntries(t *testing.T) {
+	newUserInfoStandIn(t)
+	cache := NewUserInfoCache(time.Millisecond)
+	ctx := context.Background()
+
+	for _, sub := range []string{"auth0|alice", "auth0|bob"} {
+		if _, err := cache.Get(ctx, "https://tenant.example.com/", sub, "token"); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if len(cache.entries) != 1 {
+		t.Errorf("%d entries cached, want only the last one", len(cache.entries))
+	}
+}
@@ -6,17 +6,25 @@ import (
 	"net/http"
 	"strconv"
 
-	auth "github.com/fahmiyonda007/go-gin-gorm/controllers/auth"
+	"github.com/fahmiyonda007/go-gin-gorm/controllers/handler"
 	metadata "github.com/fahmiyonda007/go-gin-gorm/controllers/pagination"
-	"github.com/fahmiyonda007/go-gin-gorm/models"
+	"github.com/fahmiyonda007/go-gin-gorm/middleware"
+	"github.com/fahmiyonda007/go-gin-gorm/repository"
+	"github.com/fahmiyonda007/go-gin-gorm/service"
 	"github.com/gin-gonic/gin"
 )
 
-var resource = "author"
-var readPermission = "read:" + resource
-var createPermission = "create:" + resource
-var updatePermission = "update:" + resource
-var deletePermission = "delete:" + resource
+// AuthorController serves the authors routes.
+type AuthorController struct {
+	authors    *service.AuthorService
+	authorizer *middleware.Authorizer
+}
+
+// NewAuthorController creates an AuthorController on authors, letting
+// callers modify what authorizer allows.
+func NewAuthorController(authors *service.AuthorService, authorizer *middleware.Authorizer) *AuthorController {
+	return &AuthorController{authors: authors, authorizer: authorizer}
+}
 
 //	@BasePath	/api/v1
 //
@@ -28,33 +36,30 @@ var deletePermission = "delete:" + resource
 //	@Tags			Authors
 //	@Param			page	query	int	false	"page"
 //	@Param			length	query	int	false	"length"
+//	@Param			mine	query	bool	false	"only the authors created by the caller"
 //	@Accept			json
 //	@Produce		json
 //	@Success		200	{array}		AuthorOutput
 //	@Failure		401	{object}	handler.JSONResult
 //	@Router			/authors [get]
 //	@Security		BearerAuth
-func Authors(c *gin.Context) {
-	token, err := auth.Authenticate(c)
+//	@Security		ApiKeyAuth
+func (ctl *AuthorController) Authors(c *gin.Context) {
+	//url.domain?page=1&length=10
+	page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+	length, _ := strconv.ParseInt(c.DefaultQuery("length", "10"), 10, 64)
 
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
+	options := repository.ListOptions{Page: int(page), Length: int(length)}
+	if c.Query("mine") == "true" {
+		options.OwnerSub = middleware.Subject(c)
 	}
 
-	if !auth.HasPermission(token, readPermission) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+	authors, count, err := ctl.authors.List(middleware.TenantContext(c), options)
+	if err != nil {
+		handler.AbortWithError(c, ctl.authorizer, err)
 		return
 	}
 
-	//url.domain?page=1&length=10
-	page, err := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
-	length, err := strconv.ParseInt(c.DefaultQuery("length", "10"), 10, 64)
-
-	var count int64
-	var authors []models.Author
-	models.DB.Find(&authors).Count(&count).Limit(metadata.Limit(int(length))).Offset(metadata.Offset(int(page), int(length))).Find(&authors)
-
 	meta := metadata.CalculateMetadata(int(count), int(page), int(length))
 	validate := metadata.ValidateFilter(meta, int(page), int(length))
 
@@ -83,22 +88,11 @@ func Authors(c *gin.Context) {
 //	@Success		200	{array}	AuthorOutput
 //	@Router			/authors/{id} [get]
 //	@Security		BearerAuth
-func Author(c *gin.Context) {
-	token, err := auth.Authenticate(c)
-
+//	@Security		ApiKeyAuth
+func (ctl *AuthorController) Author(c *gin.Context) {
+	author, err := ctl.authors.Get(middleware.TenantContext(c), parseID(c))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
-	}
-
-	if !auth.HasPermission(token, readPermission) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
-		return
-	}
-
-	var author models.Author
-	if err := models.DB.Where("id = ?", c.Param("id")).First(&author).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Record not found!"})
+		handler.AbortWithError(c, ctl.authorizer, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"data": author})
@@ -118,19 +112,8 @@ func Author(c *gin.Context) {
 //	@Success		200	{array}	AuthorOutput
 //	@Router			/authors [post]
 //	@Security		BearerAuth
-func CreateAuthor(c *gin.Context) {
-	token, err := auth.Authenticate(c)
-
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
-	}
-
-	if !auth.HasPermission(token, createPermission) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
-		return
-	}
-
+//	@Security		ApiKeyAuth
+func (ctl *AuthorController) CreateAuthor(c *gin.Context) {
 	// Validate input
 	var input CreateAuthorInput
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -141,8 +124,11 @@ func CreateAuthor(c *gin.Context) {
 		return
 	}
 	// Create author
-	author := models.Author{Name: input.Name}
-	models.DB.Create(&author)
+	author, err := ctl.authors.Create(middleware.TenantContext(c), handler.Actor(c, ctl.authorizer), service.AuthorInput{Name: input.Name})
+	if err != nil {
+		handler.AbortWithError(c, ctl.authorizer, err)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"data": author})
 
@@ -163,29 +149,8 @@ func CreateAuthor(c *gin.Context) {
 //	@Success		200	{array}	AuthorOutput
 //	@Router			/authors/{id} [patch]
 //	@Security		BearerAuth
-func UpdateAuthor(c *gin.Context) {
-	token, err := auth.Authenticate(c)
-
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
-	}
-
-	if !auth.HasPermission(token, updatePermission) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
-		return
-	}
-
-	// Get model if exist
-	var author models.Author
-	if err := models.DB.Where("id = ?", c.Param("id")).First(&author).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":  http.StatusBadRequest,
-			"error": "Record not found!",
-		})
-		return
-	}
-
+//	@Security		ApiKeyAuth
+func (ctl *AuthorController) UpdateAuthor(c *gin.Context) {
 	// Validate input
 	var input UpdateAuthorInput
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -196,7 +161,11 @@ func UpdateAuthor(c *gin.Context) {
 		return
 	}
 
-	models.DB.Model(&author).Updates(input)
+	author, err := ctl.authors.Update(middleware.TenantContext(c), handler.Actor(c, ctl.authorizer), parseID(c), service.AuthorInput{Name: input.Name})
+	if err != nil {
+		handler.AbortWithError(c, ctl.authorizer, err)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"data": author})
 }
@@ -209,36 +178,41 @@ func UpdateAuthor(c *gin.Context) {
 //	@Schemes
 //	@Description	Delete a author
 //	@Tags			Authors
-//	@Param			id	path	int	true	"id"
+//	@Param			id		path	int		true	"id"
+//	@Param			onBooks	query	string	false	"what to do with the books of the author"	Enums(restrict, cascade, reassign)	default(restrict)
+//	@Param			to		query	int		false	"author that gets the books with onBooks=reassign"
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{array}	AuthorOutput
+//	@Success		200	{array}		AuthorOutput
+//	@Failure		401	{object}	middleware.StepUpOutput
+//	@Failure		409	{object}	handler.JSONResult
 //	@Router			/authors/{id} [delete]
 //	@Security		BearerAuth
-func DeleteAuthor(c *gin.Context) {
-	token, err := auth.Authenticate(c)
-
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
+//	@Security		ApiKeyAuth
+func (ctl *AuthorController) DeleteAuthor(c *gin.Context) {
+	options := service.DeleteAuthorOptions{OnBooks: service.OnBooks(c.Query("onBooks"))}
+	if to := c.Query("to"); to != "" {
+		id, err := strconv.ParseUint(to, 10, 0)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":  http.StatusBadRequest,
+				"error": "to must be an author id",
+			})
+			return
+		}
+		options.ReassignTo = uint(id)
 	}
 
-	if !auth.HasPermission(token, deletePermission) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+	if err := ctl.authors.Delete(middleware.TenantContext(c), handler.Actor(c, ctl.authorizer), parseID(c), options); err != nil {
+		handler.AbortWithError(c, ctl.authorizer, err)
 		return
 	}
 
-	// Get model if exist
-	var author models.Author
-	if err := models.DB.Where("id = ?", c.Param("id")).First(&author).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":  http.StatusBadRequest,
-			"error": "Record not found!",
-		})
-		return
-	}
-
-	models.DB.Delete(&author)
-
 	c.JSON(http.StatusOK, gin.H{"data": true})
 }
+
+// parseID reads the id path parameter, an invalid one finds nothing.
+func parseID(c *gin.Context) uint {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 0)
+	return uint(id)
+}
@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/fahmiyonda007/go-gin-gorm/controllers/controllertest"
+	"github.com/fahmiyonda007/go-gin-gorm/middleware"
+	"github.com/fahmiyonda007/go-gin-gorm/migrations"
+	"github.com/fahmiyonda007/go-gin-gorm/models"
+	"github.com/fahmiyonda007/go-gin-gorm/repository"
+	"github.com/fahmiyonda007/go-gin-gorm/service"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestRouter serves the authors routes on in-memory repositories, see
+// controllertest.NewRouter for the caller.
+func newTestRouter(t *testing.T, config middleware.AuthorizerConfig) (*gin.Engine, *repository.MemoryBookRepository) {
+	t.Helper()
+
+	authors := repository.NewMemoryAuthorRepository()
+	books := repository.NewMemoryBookRepository(authors)
+	ctl := NewAuthorController(service.NewAuthorService(authors, books, &repository.MemoryTransactor{}), middleware.NewAuthorizer(config))
+
+	r := controllertest.NewRouter()
+	r.GET("/authors", ctl.Authors)
+	r.GET("/authors/:id", ctl.Author)
+	r.POST("/authors", ctl.CreateAuthor)
+	r.PATCH("/authors/:id", ctl.UpdateAuthor)
+	r.DELETE("/authors/:id", ctl.DeleteAuthor)
+
+	return r, books
+}
+
+// createAuthor creates an author through the API and returns its path.
+func createAuthor(t *testing.T, r *gin.Engine, name string, sub string, org string) (uint, string) {
+	t.Helper()
+
+	w := controllertest.Request(r, http.MethodPost, "/authors", `{"name":"`+name+`"}`, sub, org)
+	if w.Code != http.StatusOK {
+		t.Fatalf("create: status = %d, want 200: %s", w.Code, w.Body)
+	}
+
+	var body struct {
+		Data models.Author `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	return body.Data.ID, "/authors/" + strconv.FormatUint(uint64(body.Data.ID), 10)
+}
+
+// createBook stores a book of authorID directly in the repository.
+func createBook(t *testing.T, books *repository.MemoryBookRepository, authorID uint, sub string, org string) uint {
+	t.Helper()
+
+	book := models.Book{Title: "Bumi Manusia", AuthorId: authorID, OwnerSub: sub}
+	if err := books.Create(models.WithTenant(context.Background(), org), &book); err != nil {
+		t.Fatal(err)
+	}
+	return book.ID
+}
+
+func bookAuthor(t *testing.T, books *repository.MemoryBookRepository, id uint, org string) (uint, bool) {
+	t.Helper()
+
+	book, err := books.Get(models.WithTenant(context.Background(), org), id)
+	if err != nil {
+		return 0, false
+	}
+	return book.AuthorId, true
+}
+
+func TestAuthorLifecycle(t *testing.T) {
+	r, _ := newTestRouter(t, middleware.AuthorizerConfig{})
+	_, path := createAuthor(t, r, "Pramoedya", "auth0|alice", "o1")
+
+	if w := controllertest.Request(r, http.MethodPatch, path, `{"name":"Pramoedya Ananta Toer"}`, "auth0|alice", "o1"); w.Code != http.StatusOK {
+		t.Errorf("update: status = %d, want 200: %s", w.Code, w.Body)
+	}
+	if w := controllertest.Request(r, http.MethodGet, "/authors", "", "auth0|alice", "o1"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Pramoedya Ananta Toer") {
+		t.Errorf("list: status = %d: %s", w.Code, w.Body)
+	}
+	if w := controllertest.Request(r, http.MethodGet, path, "", "auth0|bob", "o2"); w.Code != http.StatusNotFound {
+		t.Errorf("get from another org: status = %d, want 404: %s", w.Code, w.Body)
+	}
+	if w := controllertest.Request(r, http.MethodPost, "/authors", `{`, "auth0|alice", "o1"); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid json: status = %d, want 400: %s", w.Code, w.Body)
+	}
+	if w := controllertest.Request(r, http.MethodDelete, path, "", "auth0|alice", "o1"); w.Code != http.StatusOK {
+		t.Errorf("delete: status = %d, want 200: %s", w.Code, w.Body)
+	}
+	if w := controllertest.Request(r, http.MethodGet, path, "", "auth0|alice", "o1"); w.Code != http.StatusNotFound {
+		t.Errorf("get after delete: status = %d, want 404: %s", w.Code, w.Body)
+	}
+}
+
+func TestDeleteAuthorWithBooks(t *testing.T) {
+	t.Run("restrict", func(t *testing.T) {
+		r, books := newTestRouter(t, middleware.AuthorizerConfig{})
+		id, path := createAuthor(t, r, "Pramoedya", "auth0|alice", "o1")
+		book := createBook(t, books, id, "auth0|alice", "o1")
+
+		if w := controllertest.Request(r, http.MethodDelete, path, "", "auth0|alice", "o1"); w.Code != http.StatusConflict {
+			t.Errorf("status = %d, want 409: %s", w.Code, w.Body)
+		}
+		if _, ok := bookAuthor(t, books, book, "o1"); !ok {
+			t.Error("the book was deleted")
+		}
+	})
+
+	t.Run("cascade", func(t *testing.T) {
+		r, books := newTestRouter(t, middleware.AuthorizerConfig{})
+		id, path := createAuthor(t, r, "Pramoedya", "auth0|alice", "o1")
+		book := createBook(t, books, id, "auth0|alice", "o1")
+
+		if w := controllertest.Request(r, http.MethodDelete, path+"?onBooks=cascade", "", "auth0|alice", "o1"); w.Code != http.StatusOK {
+			t.Errorf("status = %d, want 200: %s", w.Code, w.Body)
+		}
+		if _, ok := bookAuthor(t, books, book, "o1"); ok {
+			t.Error("the book was kept")
+		}
+	})
+
+	t.Run("reassign", func(t *testing.T) {
+		r, books := newTestRouter(t, middleware.AuthorizerConfig{})
+		id, path := createAuthor(t, r, "Pramoedya", "auth0|alice", "o1")
+		to, _ := createAuthor(t, r, "Multatuli", "auth0|alice", "o1")
+		book := createBook(t, books, id, "auth0|alice", "o1")
+
+		w := controllertest.Request(r, http.MethodDelete, path+"?onBooks=reassign&to="+strconv.FormatUint(uint64(to), 10), "", "auth0|alice", "o1")
+		if w.Code != http.StatusOK {
+			t.Errorf("status = %d, want 200: %s", w.Code, w.Body)
+		}
+		if author, _ := bookAuthor(t, books, book, "o1"); author != to {
+			t.Errorf("book author = %d, want %d", author, to)
+		}
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		r, books := newTestRouter(t, middleware.AuthorizerConfig{})
+		id, path := createAuthor(t, r, "Pramoedya", "auth0|alice", "o1")
+		createBook(t, books, id, "auth0|alice", "o1")
+		other, _ := createAuthor(t, r, "Multatuli", "auth0|bob", "o2")
+
+		for _, query := range []string{
+			"?onBooks=orphan",
+			"?onBooks=reassign",
+			"?onBooks=reassign&to=abc",
+			"?onBooks=reassign&to=" + strconv.FormatUint(uint64(id), 10),
+			"?onBooks=reassign&to=" + strconv.FormatUint(uint64(other), 10),
+		} {
+			if w := controllertest.Request(r, http.MethodDelete, path+query, "", "auth0|alice", "o1"); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: status = %d, want 400: %s", query, w.Code, w.Body)
+			}
+		}
+	})
+}
+
+func TestAuthorOwnership(t *testing.T) {
+	r, books := newTestRouter(t, middleware.AuthorizerConfig{Ownership: true})
+	id, path := createAuthor(t, r, "Pramoedya", "auth0|alice", "o1")
+
+	if w := controllertest.Request(r, http.MethodPatch, path, `{"name":"Stolen"}`, "auth0|bob", "o1"); w.Code != http.StatusForbidden {
+		t.Errorf("update by another user: status = %d, want 403: %s", w.Code, w.Body)
+	}
+
+	// Cascading deletes the books too, so it needs the right on each of them.
+	createBook(t, books, id, "auth0|bob", "o1")
+	if w := controllertest.Request(r, http.MethodDelete, path+"?onBooks=cascade", "", "auth0|alice", "o1"); w.Code != http.StatusForbidden {
+		t.Errorf("cascade over another user's book: status = %d, want 403: %s", w.Code, w.Body)
+	}
+	if w := controllertest.Request(r, http.MethodDelete, path+"?onBooks=cascade", "", "auth0|carol", "o1", middleware.AdminPermission); w.Code != http.StatusOK {
+		t.Errorf("cascade by an admin: status = %d, want 200: %s", w.Code, w.Body)
+	}
+}
+
+// TestAuthorOwnershipDenialOnSQLite checks that a denial is audited
+// without writing to the database while the service's transaction holds
+// it, which SQLite answers with a lock held until restart.
+func TestAuthorOwnershipDenialOnSQLite(t *testing.T) {
+	db, err := models.Open(models.DatabaseConfig{Driver: "sqlite", Name: ":memory:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
+
+	authorizer := middleware.NewAuthorizer(middleware.AuthorizerConfig{
+		Ownership: true,
+		Auditor:   middleware.NewAuditor(db),
+	})
+	authors := repository.NewGormAuthorRepository(db)
+	ctl := NewAuthorController(service.NewAuthorService(authors, repository.NewGormBookRepository(db), repository.NewGormTransactor(db)), authorizer)
+
+	r := controllertest.NewRouter()
+	r.GET("/authors/:id", ctl.Author)
+	r.POST("/authors", ctl.CreateAuthor)
+	r.PATCH("/authors/:id", ctl.UpdateAuthor)
+
+	_, path := createAuthor(t, r, "Pramoedya", "auth0|alice", "o1")
+
+	if w := controllertest.Request(r, http.MethodPatch, path, `{"name":"Stolen"}`, "auth0|bob", "o1"); w.Code != http.StatusForbidden {
+		t.Fatalf("update by another user: status = %d, want 403: %s", w.Code, w.Body)
+	}
+
+	var events []models.AuditEvent
+	if err := db.Where("type = ?", models.AuditPermissionDenied).Find(&events).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Sub != "auth0|bob" || events[0].Reason != "Not the owner" {
+		t.Errorf("audit events = %+v, want the denial of auth0|bob", events)
+	}
+
+	if w := controllertest.Request(r, http.MethodGet, path, "", "auth0|alice", "o1"); w.Code != http.StatusOK {
+		t.Errorf("get after the denial: status = %d, want 200: %s", w.Code, w.Body)
+	}
+}
@@ -6,17 +6,25 @@ import (
 	"net/http"
 	"strconv"
 
-	auth "github.com/fahmiyonda007/go-gin-gorm/controllers/auth"
+	"github.com/fahmiyonda007/go-gin-gorm/controllers/handler"
 	metadata "github.com/fahmiyonda007/go-gin-gorm/controllers/pagination"
-	"github.com/fahmiyonda007/go-gin-gorm/models"
+	"github.com/fahmiyonda007/go-gin-gorm/middleware"
+	"github.com/fahmiyonda007/go-gin-gorm/repository"
+	"github.com/fahmiyonda007/go-gin-gorm/service"
 	"github.com/gin-gonic/gin"
 )
 
-var resource = "book"
-var readPermission = "read:" + resource
-var createPermission = "create:" + resource
-var updatePermission = "update:" + resource
-var deletePermission = "delete:" + resource
+// BookController serves the books routes.
+type BookController struct {
+	books      *service.BookService
+	authorizer *middleware.Authorizer
+}
+
+// NewBookController creates a BookController on books, letting callers
+// modify what authorizer allows.
+func NewBookController(books *service.BookService, authorizer *middleware.Authorizer) *BookController {
+	return &BookController{books: books, authorizer: authorizer}
+}
 
 //	@BasePath	/api/v1
 //
@@ -28,33 +36,30 @@ var deletePermission = "delete:" + resource
 //	@Tags			Books
 //	@Param			page	query	int	false	"page"
 //	@Param			length	query	int	false	"length"
+//	@Param			mine	query	bool	false	"only the books created by the caller"
 //	@Accept			json
 //	@Produce		json
 //	@Success		200	{array}		BookOutput
 //	@Failure		401	{object}	handler.JSONResult
 //	@Router			/books [get]
 //	@Security		BearerAuth
-func Books(c *gin.Context) {
-	token, err := auth.Authenticate(c)
+//	@Security		ApiKeyAuth
+func (ctl *BookController) Books(c *gin.Context) {
+	//url.domain?page=1&length=10
+	page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+	length, _ := strconv.ParseInt(c.DefaultQuery("length", "10"), 10, 64)
 
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
+	options := repository.ListOptions{Page: int(page), Length: int(length)}
+	if c.Query("mine") == "true" {
+		options.OwnerSub = middleware.Subject(c)
 	}
 
-	if !auth.HasPermission(token, readPermission) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+	books, count, err := ctl.books.List(middleware.TenantContext(c), options)
+	if err != nil {
+		handler.AbortWithError(c, ctl.authorizer, err)
 		return
 	}
 
-	//url.domain?page=1&length=10
-	page, err := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
-	length, err := strconv.ParseInt(c.DefaultQuery("length", "10"), 10, 64)
-
-	var count int64
-	var books []models.Book
-	models.DB.Find(&books).Count(&count).Limit(metadata.Limit(int(length))).Offset(metadata.Offset(int(page), int(length))).Preload("Author").Find(&books)
-
 	meta := metadata.CalculateMetadata(int(count), int(page), int(length))
 	validate := metadata.ValidateFilter(meta, int(page), int(length))
 
@@ -83,22 +88,11 @@ func Books(c *gin.Context) {
 //	@Success		200	{array}	BookOutput
 //	@Router			/books/{id} [get]
 //	@Security		BearerAuth
-func Book(c *gin.Context) {
-	token, err := auth.Authenticate(c)
-
+//	@Security		ApiKeyAuth
+func (ctl *BookController) Book(c *gin.Context) {
+	book, err := ctl.books.Get(middleware.TenantContext(c), parseID(c))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
-	}
-
-	if !auth.HasPermission(token, readPermission) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
-		return
-	}
-
-	var book models.Book
-	if err := models.DB.Where("id = ?", c.Param("id")).Preload("Author").First(&book).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Record not found!"})
+		handler.AbortWithError(c, ctl.authorizer, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"data": book})
@@ -118,19 +112,8 @@ func Book(c *gin.Context) {
 //	@Success		200	{array}	BookOutput
 //	@Router			/books [post]
 //	@Security		BearerAuth
-func CreateBook(c *gin.Context) {
-	token, err := auth.Authenticate(c)
-
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
-	}
-
-	if !auth.HasPermission(token, createPermission) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
-		return
-	}
-
+//	@Security		ApiKeyAuth
+func (ctl *BookController) CreateBook(c *gin.Context) {
 	// Validate input
 	var input CreateBookInput
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -140,9 +123,16 @@ func CreateBook(c *gin.Context) {
 		})
 		return
 	}
+
 	// Create book
-	book := models.Book{Title: input.Title, AuthorId: input.AuthorId}
-	models.DB.Create(&book).Preload("Author").First(&book)
+	book, err := ctl.books.Create(middleware.TenantContext(c), handler.Actor(c, ctl.authorizer), service.BookInput{
+		Title:    input.Title,
+		AuthorID: input.AuthorId,
+	})
+	if err != nil {
+		handler.AbortWithError(c, ctl.authorizer, err)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"data": book})
 
@@ -163,29 +153,8 @@ func CreateBook(c *gin.Context) {
 //	@Success		200	{array}	BookOutput
 //	@Router			/books/{id} [patch]
 //	@Security		BearerAuth
-func UpdateBook(c *gin.Context) {
-	token, err := auth.Authenticate(c)
-
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
-	}
-
-	if !auth.HasPermission(token, updatePermission) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
-		return
-	}
-
-	// Get model if exist
-	var book models.Book
-	if err := models.DB.Where("id = ?", c.Param("id")).First(&book).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":  http.StatusBadRequest,
-			"error": "Record not found!",
-		})
-		return
-	}
-
+//	@Security		ApiKeyAuth
+func (ctl *BookController) UpdateBook(c *gin.Context) {
 	// Validate input
 	var input UpdateBookInput
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -196,17 +165,15 @@ func UpdateBook(c *gin.Context) {
 		return
 	}
 
-	var author models.Author
-	if err := models.DB.Where("id = ?", input.AuthorId).First(&author).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":  http.StatusBadRequest,
-			"error": "Record Author ID not found!",
-		})
+	book, err := ctl.books.Update(middleware.TenantContext(c), handler.Actor(c, ctl.authorizer), parseID(c), service.BookInput{
+		Title:    input.Title,
+		AuthorID: input.AuthorId,
+	})
+	if err != nil {
+		handler.AbortWithError(c, ctl.authorizer, err)
 		return
 	}
 
-	models.DB.Model(&book).Updates(input).Preload("Author").First(&book)
-
 	c.JSON(http.StatusOK, gin.H{"data": book})
 }
 
@@ -221,33 +188,22 @@ func UpdateBook(c *gin.Context) {
 //	@Param			id	path	int	true	"id"
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{array}	BookOutput
+//	@Success		200	{array}		BookOutput
+//	@Failure		401	{object}	middleware.StepUpOutput
 //	@Router			/books/{id} [delete]
 //	@Security		BearerAuth
-func DeleteBook(c *gin.Context) {
-	token, err := auth.Authenticate(c)
-
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
-	}
-
-	if !auth.HasPermission(token, deletePermission) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
-		return
-	}
-
-	// Get model if exist
-	var book models.Book
-	if err := models.DB.Where("id = ?", c.Param("id")).First(&book).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":  http.StatusBadRequest,
-			"error": "Record not found!",
-		})
+//	@Security		ApiKeyAuth
+func (ctl *BookController) DeleteBook(c *gin.Context) {
+	if err := ctl.books.Delete(middleware.TenantContext(c), handler.Actor(c, ctl.authorizer), parseID(c)); err != nil {
+		handler.AbortWithError(c, ctl.authorizer, err)
 		return
 	}
 
-	models.DB.Delete(&book)
-
 	c.JSON(http.StatusOK, gin.H{"data": true})
 }
+
+// parseID reads the id path parameter, an invalid one finds nothing.
+func parseID(c *gin.Context) uint {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 0)
+	return uint(id)
+}
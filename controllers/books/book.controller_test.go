@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fahmiyonda007/go-gin-gorm/controllers/controllertest"
+	"github.com/fahmiyonda007/go-gin-gorm/middleware"
+	"github.com/fahmiyonda007/go-gin-gorm/models"
+	"github.com/fahmiyonda007/go-gin-gorm/repository"
+	"github.com/fahmiyonda007/go-gin-gorm/service"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestRouter serves the books routes on in-memory repositories, see
+// controllertest.NewRouter for the caller.
+func newTestRouter(t *testing.T, config middleware.AuthorizerConfig) (*gin.Engine, *repository.MemoryAuthorRepository) {
+	t.Helper()
+
+	authors := repository.NewMemoryAuthorRepository()
+	books := repository.NewMemoryBookRepository(authors)
+	ctl := NewBookController(service.NewBookService(books, authors, &repository.MemoryTransactor{}), middleware.NewAuthorizer(config))
+
+	r := controllertest.NewRouter()
+	r.GET("/books", ctl.Books)
+	r.GET("/books/:id", ctl.Book)
+	r.POST("/books", ctl.CreateBook)
+	r.PATCH("/books/:id", ctl.UpdateBook)
+	r.DELETE("/books/:id", ctl.DeleteBook)
+
+	return r, authors
+}
+
+// createAuthor stores an author of org directly in the repository.
+func createAuthor(t *testing.T, authors *repository.MemoryAuthorRepository, org string) uint {
+	t.Helper()
+
+	author := models.Author{Name: "Pramoedya"}
+	if err := authors.Create(models.WithTenant(context.Background(), org), &author); err != nil {
+		t.Fatal(err)
+	}
+	return author.ID
+}
+
+func decodeBook(t *testing.T, w *httptest.ResponseRecorder) models.Book {
+	t.Helper()
+
+	var body struct {
+		Data models.Book `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("could not decode %q: %v", w.Body, err)
+	}
+	return body.Data
+}
+
+func TestBookLifecycle(t *testing.T) {
+	r, authors := newTestRouter(t, middleware.AuthorizerConfig{})
+	authorID := createAuthor(t, authors, "o1")
+
+	w := controllertest.Request(r, http.MethodPost, "/books", `{"title":"Bumi Manusia","authorId":`+jsonID(authorID)+`}`, "auth0|alice", "o1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("create: status = %d, want 200: %s", w.Code, w.Body)
+	}
+	book := decodeBook(t, w)
+	if book.OwnerSub != "auth0|alice" || book.Author.ID != authorID {
+		t.Errorf("created %+v, want owned by alice with its author", book)
+	}
+
+	path := "/books/" + jsonID(book.ID)
+
+	w = controllertest.Request(r, http.MethodPatch, path, `{"title":"Anak Semua Bangsa"}`, "auth0|alice", "o1")
+	if w.Code != http.StatusOK || decodeBook(t, w).Title != "Anak Semua Bangsa" {
+		t.Errorf("update: status = %d: %s", w.Code, w.Body)
+	}
+
+	w = controllertest.Request(r, http.MethodGet, "/books", "", "auth0|alice", "o1")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Anak Semua Bangsa") {
+		t.Errorf("list: status = %d: %s", w.Code, w.Body)
+	}
+
+	w = controllertest.Request(r, http.MethodDelete, path, "", "auth0|alice", "o1")
+	if w.Code != http.StatusOK {
+		t.Errorf("delete: status = %d, want 200: %s", w.Code, w.Body)
+	}
+
+	w = controllertest.Request(r, http.MethodGet, path, "", "auth0|alice", "o1")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("get after delete: status = %d, want 404: %s", w.Code, w.Body)
+	}
+}
+
+func TestBookErrors(t *testing.T) {
+	r, authors := newTestRouter(t, middleware.AuthorizerConfig{})
+	authorID := createAuthor(t, authors, "o1")
+
+	w := controllertest.Request(r, http.MethodPost, "/books", `{"title":"Bumi Manusia","authorId":`+jsonID(authorID)+`}`, "auth0|alice", "o1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("create: status = %d, want 200: %s", w.Code, w.Body)
+	}
+	path := "/books/" + jsonID(decodeBook(t, w).ID)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		org    string
+		want   int
+	}{
+		{"missing title", http.MethodPost, "/books", `{"authorId":1}`, "o1", http.StatusBadRequest},
+		{"unknown author", http.MethodPost, "/books", `{"title":"Rumah Kaca","authorId":99}`, "o1", http.StatusBadRequest},
+		{"author of another org", http.MethodPost, "/books", `{"title":"Rumah Kaca","authorId":` + jsonID(authorID) + `}`, "o2", http.StatusBadRequest},
+		{"get from another org", http.MethodGet, path, "", "o2", http.StatusNotFound},
+		{"update from another org", http.MethodPatch, path, `{"title":"Stolen"}`, "o2", http.StatusNotFound},
+		{"delete from another org", http.MethodDelete, path, "", "o2", http.StatusNotFound},
+		{"invalid id", http.MethodGet, "/books/abc", "", "o1", http.StatusNotFound},
+		{"page out of range", http.MethodGet, "/books?page=5", "", "o1", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := controllertest.Request(r, tt.method, tt.path, tt.body, "auth0|alice", tt.org)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestBookOwnership(t *testing.T) {
+	r, authors := newTestRouter(t, middleware.AuthorizerConfig{Ownership: true})
+	authorID := createAuthor(t, authors, "o1")
+
+	w := controllertest.Request(r, http.MethodPost, "/books", `{"title":"Bumi Manusia","authorId":`+jsonID(authorID)+`}`, "auth0|alice", "o1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("create: status = %d, want 200: %s", w.Code, w.Body)
+	}
+	path := "/books/" + jsonID(decodeBook(t, w).ID)
+
+	if w := controllertest.Request(r, http.MethodPatch, path, `{"title":"Stolen"}`, "auth0|bob", "o1"); w.Code != http.StatusForbidden {
+		t.Errorf("update by another user: status = %d, want 403: %s", w.Code, w.Body)
+	}
+	if w := controllertest.Request(r, http.MethodDelete, path, "", "auth0|bob", "o1"); w.Code != http.StatusForbidden {
+		t.Errorf("delete by another user: status = %d, want 403: %s", w.Code, w.Body)
+	}
+	if w := controllertest.Request(r, http.MethodPatch, path, `{"title":"Edited"}`, "auth0|carol", "o1", middleware.AdminPermission); w.Code != http.StatusOK {
+		t.Errorf("update by an admin: status = %d, want 200: %s", w.Code, w.Body)
+	}
+	if w := controllertest.Request(r, http.MethodDelete, path, "", "auth0|alice", "o1"); w.Code != http.StatusOK {
+		t.Errorf("delete by the owner: status = %d, want 200: %s", w.Code, w.Body)
+	}
+}
+
+func jsonID(id uint) string {
+	data, _ := json.Marshal(id)
+	return string(data)
+}
@@ -0,0 +1,80 @@
+// controllers/dev.go
+
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/fahmiyonda007/go-gin-gorm/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultExpiresIn is the lifetime in seconds of a minted token when the
+// request does not ask for one.
+const defaultExpiresIn = 3600
+
+//	@BasePath	/api/v1
+
+// Token godoc
+//
+// Without an issuer holding a private key there is nothing to mint with,
+// and the route answers 404 as if it did not exist.
+//
+//	@Summary	mint a development token
+//	@Schemes
+//	@Description	mint a token signed by the local issuer, only available when AUTH_MODE=local
+//	@Tags			Development
+//	@Param			input	body	TokenInput	true	"Input"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	TokenOutput
+//	@Failure		404	{object}	handler.JSONResult
+//	@Router			/dev/token [post]
+func Token(issuer *middleware.LocalVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if issuer == nil || !issuer.CanSign() {
+			c.JSON(http.StatusNotFound, gin.H{
+				"code":  http.StatusNotFound,
+				"error": "Not found",
+			})
+			return
+		}
+
+		// Validate input
+		var input TokenInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":  http.StatusBadRequest,
+				"error": err.Error(),
+			})
+			return
+		}
+
+		if input.ExpiresIn <= 0 {
+			input.ExpiresIn = defaultExpiresIn
+		}
+
+		token, err := issuer.Mint(input.Subject, middleware.CustomClaims{
+			Scope:       input.Scope,
+			Permissions: input.Permissions,
+			Roles:       input.Roles,
+			OrgID:       input.OrgID,
+			AuthTime:    time.Now().Unix(),
+			AMR:         input.AMR,
+		}, time.Duration(input.ExpiresIn)*time.Second)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":  http.StatusInternalServerError,
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, TokenOutput{
+			AccessToken: token,
+			TokenType:   "Bearer",
+			ExpiresIn:   input.ExpiresIn,
+		})
+	}
+}
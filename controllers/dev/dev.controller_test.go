@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/fahmiyonda007/go-gin-gorm/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func postToken(issuer *middleware.LocalVerifier, body string) *httptest.ResponseRecorder {
+	r := gin.New()
+	r.POST("/dev/token", Token(issuer))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/dev/token", strings.NewReader(body)))
+	return w
+}
+
+func TestToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer, err := middleware.NewLocalVerifier("http://localhost:8080/", "https://books.example.com", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("mints a token the issuer accepts", func(t *testing.T) {
+		w := postToken(issuer, `{"subject":"auth0|alice","permissions":["read:book"],"orgId":"o1"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+		}
+
+		var output TokenOutput
+		if err := json.Unmarshal(w.Body.Bytes(), &output); err != nil {
+			t.Fatal(err)
+		}
+		if output.ExpiresIn != defaultExpiresIn {
+			t.Errorf("expires in = %d, want %d", output.ExpiresIn, defaultExpiresIn)
+		}
+
+		claims, err := issuer.ValidateToken(context.Background(), output.AccessToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		customClaims := claims.(*validator.ValidatedClaims).CustomClaims.(*middleware.CustomClaims)
+		if customClaims.OrgID != "o1" || len(customClaims.Permissions) != 1 || customClaims.AuthTime == 0 {
+			t.Errorf("claims = %+v, want o1 with read:book and an auth_time", customClaims)
+		}
+	})
+
+	t.Run("missing subject", func(t *testing.T) {
+		if w := postToken(issuer, `{}`); w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400: %s", w.Code, w.Body)
+		}
+	})
+}
+
+func TestTokenWithoutPrivateKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "public.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	verifyOnly, err := middleware.NewLocalVerifierFromPEM("http://localhost:8080/", "https://books.example.com", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, issuer := range map[string]*middleware.LocalVerifier{"public key only": verifyOnly, "auth0": nil} {
+		t.Run(name, func(t *testing.T) {
+			if w := postToken(issuer, `{"subject":"auth0|alice"}`); w.Code != http.StatusNotFound {
+				t.Errorf("status = %d, want 404: %s", w.Code, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package controllers
+
+type TokenInput struct {
+	Subject     string   `json:"subject" binding:"required"`
+	Permissions []string `json:"permissions"`
+	Roles       []string `json:"roles"`
+	OrgID       string   `json:"orgId"`
+	Scope       string   `json:"scope"`
+	AMR         []string `json:"amr"`
+	ExpiresIn   int      `json:"expiresIn"`
+}
+
+type TokenOutput struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
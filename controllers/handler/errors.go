@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/fahmiyonda007/go-gin-gorm/middleware"
+	"github.com/fahmiyonda007/go-gin-gorm/service"
+	"github.com/gin-gonic/gin"
+)
+
+// Actor is the caller of c, as the services see them, with what they
+// may modify decided by authorizer.
+func Actor(c *gin.Context, authorizer *middleware.Authorizer) service.Actor {
+	return service.Actor{
+		Subject: middleware.Subject(c),
+		CanModify: func(ownerSub string) bool {
+			return authorizer.CanModify(c, ownerSub)
+		},
+	}
+}
+
+// AbortWithError answers with the status code of a service error:
+// 404 when not found, 400 for invalid input, 403 when forbidden, 409 on
+// conflict and 500 otherwise. Forbidden errors are audited by authorizer
+// here, after the service's transaction has ended. The details of other
+// errors are logged rather than returned, as they may describe the
+// database.
+func AbortWithError(c *gin.Context, authorizer *middleware.Authorizer, err error) {
+	var (
+		notFound   *service.NotFoundError
+		validation *service.ValidationError
+		forbidden  *service.ForbiddenError
+		conflict   *service.ConflictError
+	)
+
+	status := http.StatusInternalServerError
+	message := "Internal server error"
+	switch {
+	case errors.As(err, &notFound):
+		status = http.StatusNotFound
+		message = "Record not found!"
+	case errors.As(err, &validation):
+		status = http.StatusBadRequest
+		message = err.Error()
+	case errors.As(err, &forbidden):
+		status = http.StatusForbidden
+		message = err.Error()
+		authorizer.AuditNotOwner(c)
+	case errors.As(err, &conflict):
+		status = http.StatusConflict
+		message = err.Error()
+	default:
+		log.Printf("Error handling %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+	}
+
+	c.AbortWithStatusJSON(status, gin.H{
+		"code":  status,
+		"error": message,
+	})
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fahmiyonda007/go-gin-gorm/middleware"
+	"github.com/fahmiyonda007/go-gin-gorm/service"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestAbortWithError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		message string
+	}{
+		{"not found", &service.NotFoundError{Resource: "Book", ID: 1}, http.StatusNotFound, "Record not found!"},
+		{"validation", &service.ValidationError{Message: "Title is required"}, http.StatusBadRequest, "Title is required"},
+		{"forbidden", &service.ForbiddenError{Message: "Only the owner"}, http.StatusForbidden, "Only the owner"},
+		{"conflict", &service.ConflictError{Message: "The author still has books"}, http.StatusConflict, "The author still has books"},
+		{"database", errors.New("sqlite: database is locked (SQLITE_BUSY)"), http.StatusInternalServerError, "Internal server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/", func(c *gin.Context) {
+				AbortWithError(c, middleware.NewAuthorizer(middleware.AuthorizerConfig{}), tt.err)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if !strings.Contains(w.Body.String(), tt.message) || strings.Contains(w.Body.String(), "sqlite") {
+				t.Errorf("body = %s, want only %q", w.Body, tt.message)
+			}
+		})
+	}
+}
@@ -0,0 +1,410 @@
+// controllers/users.go
+
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	metadata "github.com/fahmiyonda007/go-gin-gorm/controllers/pagination"
+	"github.com/fahmiyonda007/go-gin-gorm/management"
+	"github.com/fahmiyonda007/go-gin-gorm/policy"
+	"github.com/gin-gonic/gin"
+)
+
+// catalogPermissions are the permissions of our API an admin may grant or
+// revoke here, anything else is still managed in the Auth0 dashboard.
+var catalogPermissions = map[string]bool{
+	"read:book":     true,
+	"create:book":   true,
+	"update:book":   true,
+	"delete:book":   true,
+	"read:author":   true,
+	"create:author": true,
+	"update:author": true,
+	"delete:author": true,
+}
+
+//	@BasePath	/api/v1
+//
+// Users godoc
+//
+//	@Summary	get all users
+//	@Schemes
+//	@Description	get the users of the Auth0 tenant, q is an Auth0 user search query
+//	@Tags			Users
+//	@Param			page	query	int		false	"page"
+//	@Param			length	query	int		false	"length"
+//	@Param			q		query	string	false	"search query"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		management.User
+//	@Failure		401	{object}	handler.JSONResult
+//	@Router			/admin/users [get]
+//	@Security		BearerAuth
+func Users(m *management.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		//url.domain?page=1&length=10
+		page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+		length, _ := strconv.ParseInt(c.DefaultQuery("length", "10"), 10, 64)
+
+		if page < 1 || length < 1 || length > 50 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":  http.StatusBadRequest,
+				"error": "page must be at least 1 and length between 1 and 50",
+			})
+			return
+		}
+
+		// Auth0 pages start at zero.
+		users, total, err := m.ListUsers(c.Request.Context(), c.Query("q"), int(page)-1, int(length))
+		if err != nil {
+			abortWithManagementError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"metadata": metadata.CalculateMetadata(total, int(page), int(length)),
+			"data":     users,
+		})
+	}
+}
+
+//	@BasePath	/api/v1
+//
+// Users/:id godoc
+//
+//	@Summary	find a user by id
+//	@Schemes
+//	@Description	find a user by id along with their roles and permissions
+//	@Tags			Users
+//	@Param			id	path	string	true	"Auth0 user id"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	UserOutput
+//	@Router			/admin/users/{id} [get]
+//	@Security		BearerAuth
+func User(m *management.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+
+		user, err := m.GetUser(c.Request.Context(), id)
+		if err != nil {
+			abortWithManagementError(c, err)
+			return
+		}
+
+		roles, err := m.UserRoles(c.Request.Context(), id)
+		if err != nil {
+			abortWithManagementError(c, err)
+			return
+		}
+
+		permissions, err := m.UserPermissions(c.Request.Context(), id)
+		if err != nil {
+			abortWithManagementError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": UserOutput{
+			User:        *user,
+			Roles:       roles,
+			Permissions: permissions,
+		}})
+	}
+}
+
+//	@BasePath	/api/v1
+//
+// Roles godoc
+//
+//	@Summary	get all roles
+//	@Schemes
+//	@Description	get the roles of the Auth0 tenant that can be assigned to users, those the policy file defines
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		management.Role
+//	@Router			/admin/roles [get]
+//	@Security		BearerAuth
+func Roles(m *management.Client, engine *policy.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roles, err := catalogRoles(c, m, engine)
+		if err != nil {
+			abortWithManagementError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": roles})
+	}
+}
+
+//	@BasePath	/api/v1
+//
+// Users godoc
+//
+//	@Summary	Assign roles to a user
+//	@Schemes
+//	@Description	Assign roles, by role id, to a user. Only roles the policy file defines can be assigned
+//	@Tags			Users
+//	@Param			id		path	string		true	"Auth0 user id"
+//	@Param			input	body	RolesInput	true	"Input"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	handler.JSONResult
+//	@Router			/admin/users/{id}/roles [post]
+//	@Security		BearerAuth
+func AssignRoles(m *management.Client, engine *policy.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input RolesInput
+		if !bindRoles(c, m, engine, &input) {
+			return
+		}
+
+		if err := m.AssignRoles(c.Request.Context(), c.Param("id"), input.Roles); err != nil {
+			abortWithManagementError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": true})
+	}
+}
+
+//	@BasePath	/api/v1
+//
+// Users godoc
+//
+//	@Summary	Remove roles from a user
+//	@Schemes
+//	@Description	Remove roles, by role id, from a user. Only roles the policy file defines can be removed
+//	@Tags			Users
+//	@Param			id		path	string		true	"Auth0 user id"
+//	@Param			input	body	RolesInput	true	"Input"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	handler.JSONResult
+//	@Router			/admin/users/{id}/roles [delete]
+//	@Security		BearerAuth
+func RemoveRoles(m *management.Client, engine *policy.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input RolesInput
+		if !bindRoles(c, m, engine, &input) {
+			return
+		}
+
+		if err := m.RemoveRoles(c.Request.Context(), c.Param("id"), input.Roles); err != nil {
+			abortWithManagementError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": true})
+	}
+}
+
+//	@BasePath	/api/v1
+//
+// Users godoc
+//
+//	@Summary	Grant permissions to a user
+//	@Schemes
+//	@Description	Grant book and author permissions, such as read:book, to a user
+//	@Tags			Users
+//	@Param			id		path	string				true	"Auth0 user id"
+//	@Param			input	body	PermissionsInput	true	"Input"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	handler.JSONResult
+//	@Router			/admin/users/{id}/permissions [post]
+//	@Security		BearerAuth
+func AssignPermissions(m *management.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input PermissionsInput
+		if !bindPermissions(c, &input) {
+			return
+		}
+
+		if err := m.AssignPermissions(c.Request.Context(), c.Param("id"), input.Permissions); err != nil {
+			abortWithManagementError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": true})
+	}
+}
+
+//	@BasePath	/api/v1
+//
+// Users godoc
+//
+//	@Summary	Revoke permissions from a user
+//	@Schemes
+//	@Description	Revoke book and author permissions, such as delete:book, from a user
+//	@Tags			Users
+//	@Param			id		path	string				true	"Auth0 user id"
+//	@Param			input	body	PermissionsInput	true	"Input"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	handler.JSONResult
+//	@Router			/admin/users/{id}/permissions [delete]
+//	@Security		BearerAuth
+func RemovePermissions(m *management.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input PermissionsInput
+		if !bindPermissions(c, &input) {
+			return
+		}
+
+		if err := m.RemovePermissions(c.Request.Context(), c.Param("id"), input.Permissions); err != nil {
+			abortWithManagementError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": true})
+	}
+}
+
+//	@BasePath	/api/v1
+//
+// Users godoc
+//
+//	@Summary	Block a user
+//	@Schemes
+//	@Description	Block a user so they can no longer log in
+//	@Tags			Users
+//	@Param			id	path	string	true	"Auth0 user id"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	management.User
+//	@Router			/admin/users/{id}/block [post]
+//	@Security		BearerAuth
+func BlockUser(m *management.Client) gin.HandlerFunc {
+	return setBlocked(m, true)
+}
+
+//	@BasePath	/api/v1
+//
+// Users godoc
+//
+//	@Summary	Unblock a user
+//	@Schemes
+//	@Description	Unblock a user so they can log in again
+//	@Tags			Users
+//	@Param			id	path	string	true	"Auth0 user id"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	management.User
+//	@Router			/admin/users/{id}/block [delete]
+//	@Security		BearerAuth
+func UnblockUser(m *management.Client) gin.HandlerFunc {
+	return setBlocked(m, false)
+}
+
+func setBlocked(m *management.Client, blocked bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := m.SetBlocked(c.Request.Context(), c.Param("id"), blocked)
+		if err != nil {
+			abortWithManagementError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": user})
+	}
+}
+
+func bindInput(c *gin.Context, input interface{}) bool {
+	if err := c.ShouldBindJSON(input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  http.StatusBadRequest,
+			"error": err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+func bindPermissions(c *gin.Context, input *PermissionsInput) bool {
+	if !bindInput(c, input) {
+		return false
+	}
+
+	for _, permission := range input.Permissions {
+		if !catalogPermissions[permission] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":  http.StatusBadRequest,
+				"error": "Unknown permission " + permission,
+			})
+			return false
+		}
+	}
+	return true
+}
+
+// bindRoles binds input and refuses role ids that aren't catalog roles.
+func bindRoles(c *gin.Context, m *management.Client, engine *policy.Engine, input *RolesInput) bool {
+	if !bindInput(c, input) {
+		return false
+	}
+
+	roles, err := catalogRoles(c, m, engine)
+	if err != nil {
+		abortWithManagementError(c, err)
+		return false
+	}
+
+	known := map[string]bool{}
+	for _, role := range roles {
+		known[role.ID] = true
+	}
+
+	for _, id := range input.Roles {
+		if !known[id] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":  http.StatusBadRequest,
+				"error": "Unknown role " + id,
+			})
+			return false
+		}
+	}
+	return true
+}
+
+// catalogRoles returns the Auth0 roles the policy file defines, the only
+// ones that grant anything on our API. Like permissions outside the
+// catalog, the others are still managed in the Auth0 dashboard.
+func catalogRoles(c *gin.Context, m *management.Client, engine *policy.Engine) ([]management.Role, error) {
+	roles, err := m.ListRoles(c.Request.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := []management.Role{}
+	for _, role := range roles {
+		if engine.HasRole(role.Name) {
+			catalog = append(catalog, role)
+		}
+	}
+	return catalog, nil
+}
+
+// abortWithManagementError passes on the errors of the Management API
+// caused by the request, such as an unknown user, and reports anything
+// else, including our own credentials being refused, as a bad gateway.
+func abortWithManagementError(c *gin.Context, err error) {
+	code := http.StatusBadGateway
+	message := err.Error()
+
+	var apiErr *management.Error
+	if errors.As(err, &apiErr) {
+		message = apiErr.Message
+		switch apiErr.StatusCode {
+		case http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusTooManyRequests:
+			code = apiErr.StatusCode
+		}
+	}
+
+	c.JSON(code, gin.H{
+		"code":  code,
+		"error": message,
+	})
+}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fahmiyonda007/go-gin-gorm/management"
+	"github.com/fahmiyonda007/go-gin-gorm/policy"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newManagementStandIn answers the Management API with the tenant roles
+// reader, editor and owner, and records role assignments.
+func newManagementStandIn(t *testing.T) (*management.Client, *[]string) {
+	t.Helper()
+
+	var assigned []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /oauth/token":
+			_, _ = w.Write([]byte(`{"access_token":"management-token","expires_in":86400}`))
+		case "GET /api/v2/roles":
+			_, _ = w.Write([]byte(`[
+				{"id":"rol_reader","name":"reader"},
+				{"id":"rol_editor","name":"editor"},
+				{"id":"rol_owner","name":"owner"}
+			]`))
+		case "POST /api/v2/users/auth0|alice/roles":
+			body, _ := io.ReadAll(r.Body)
+			assigned = append(assigned, string(body))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return management.New(server.URL, "id", "secret", "https://books.example.com"), &assigned
+}
+
+func newTestPolicy(t *testing.T) *policy.Engine {
+	t.Helper()
+
+	engine, err := policy.New(policy.File{Roles: map[string]policy.Role{
+		"reader": {Permissions: []string{"read:*"}},
+		"editor": {Inherits: []string{"reader"}, Permissions: []string{"create:*", "update:*"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return engine
+}
+
+func serve(r *gin.Engine, method string, path string, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return w
+}
+
+func TestRolesListsCatalogRoles(t *testing.T) {
+	m, _ := newManagementStandIn(t)
+	r := gin.New()
+	r.GET("/roles", Roles(m, newTestPolicy(t)))
+
+	w := serve(r, http.MethodGet, "/roles", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+
+	var body struct {
+		Data []management.Role `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Data) != 2 || body.Data[0].ID != "rol_reader" || body.Data[1].ID != "rol_editor" {
+		t.Errorf("roles = %+v, want reader and editor", body.Data)
+	}
+}
+
+func TestAssignRolesOnlyAcceptsCatalogRoles(t *testing.T) {
+	tests := []struct {
+		name     string
+		engine   *policy.Engine
+		roles    string
+		want     int
+		assigned bool
+	}{
+		{"catalog role", newTestPolicy(t), `["rol_editor"]`, http.StatusOK, true},
+		{"role outside the policy", newTestPolicy(t), `["rol_reader","rol_owner"]`, http.StatusBadRequest, false},
+		{"unknown role id", newTestPolicy(t), `["rol_missing"]`, http.StatusBadRequest, false},
+		{"no policy", nil, `["rol_editor"]`, http.StatusBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, assigned := newManagementStandIn(t)
+			r := gin.New()
+			r.POST("/users/:id/roles", AssignRoles(m, tt.engine))
+
+			w := serve(r, http.MethodPost, "/users/auth0|alice/roles", `{"roles":`+tt.roles+`}`)
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if got := len(*assigned) > 0; got != tt.assigned {
+				t.Errorf("assigned = %v, want %v", *assigned, tt.assigned)
+			}
+		})
+	}
+}
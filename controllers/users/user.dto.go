@@ -0,0 +1,19 @@
+package controllers
+
+import (
+	"github.com/fahmiyonda007/go-gin-gorm/management"
+)
+
+type RolesInput struct {
+	Roles []string `json:"roles" binding:"required,min=1"`
+}
+
+type PermissionsInput struct {
+	Permissions []string `json:"permissions" binding:"required,min=1"`
+}
+
+type UserOutput struct {
+	management.User
+	Roles       []management.Role `json:"roles"`
+	Permissions []string          `json:"permissions"`
+}
@@ -1,15 +1,28 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"log"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
+	apikeys "github.com/fahmiyonda007/go-gin-gorm/controllers/apikeys"
+	audit "github.com/fahmiyonda007/go-gin-gorm/controllers/audit"
 	auth "github.com/fahmiyonda007/go-gin-gorm/controllers/auth"
 	authors "github.com/fahmiyonda007/go-gin-gorm/controllers/authors"
 	controllers "github.com/fahmiyonda007/go-gin-gorm/controllers/books"
+	dev "github.com/fahmiyonda007/go-gin-gorm/controllers/dev"
+	users "github.com/fahmiyonda007/go-gin-gorm/controllers/users"
 	docs "github.com/fahmiyonda007/go-gin-gorm/docs"
+	"github.com/fahmiyonda007/go-gin-gorm/management"
 	"github.com/fahmiyonda007/go-gin-gorm/middleware"
 	"github.com/fahmiyonda007/go-gin-gorm/models"
+	"github.com/fahmiyonda007/go-gin-gorm/policy"
+	"github.com/fahmiyonda007/go-gin-gorm/repository"
+	"github.com/fahmiyonda007/go-gin-gorm/service"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	swaggerFiles "github.com/swaggo/files"
@@ -36,43 +49,187 @@ import (
 //	@in							header
 //	@name						Authorization
 //	@description				Type "Bearer" followed by a space and JWT token.
+
+//	@securityDefinitions.apikey	ApiKeyAuth
+//	@in							header
+//	@name						X-API-Key
+//	@description				API key created under /admin/api-keys.
+
 func main() {
 	if err := godotenv.Load(); err != nil {
 		log.Fatalf("Error loading the .env file: %v", err)
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
 	r := gin.Default()
+	// Only the proxies in TRUSTED_PROXIES may set X-Forwarded-For, so
+	// clients can't pick the IP the login throttle and audit log see.
+	if err := r.SetTrustedProxies(strings.Fields(os.Getenv("TRUSTED_PROXIES"))); err != nil {
+		log.Fatalf("Error parsing TRUSTED_PROXIES: %v", err)
+	}
 
 	models.ConnectDatabase()
+	requireMigrated()
+	auditor := middleware.NewAuditor(models.DB)
+
+	verifier, err := middleware.NewTokenVerifier()
+	if err != nil {
+		log.Fatalf("Error setting up the token verifier: %v", err)
+	}
+
+	sessions, err := middleware.NewSessionCodecFromEnv()
+	if err != nil {
+		log.Fatalf("Error setting up sessions: %v", err)
+	}
+
+	attempts, err := middleware.NewAttemptStoreFromEnv()
+	if err != nil {
+		log.Fatalf("Error setting up the login attempt store: %v", err)
+	}
+
+	var policyEngine *policy.Engine
+	if path := os.Getenv("POLICY_FILE"); path != "" {
+		policyEngine, err = policy.Load(path)
+		if err != nil {
+			log.Fatalf("Error loading the policy file: %v", err)
+		}
+		go policyEngine.Watch(context.Background(), 5*time.Second)
+	}
+
+	authorizer := middleware.NewAuthorizer(middleware.AuthorizerConfig{
+		Policy:    policyEngine,
+		Ownership: os.Getenv("OWNERSHIP_MODE") == "true",
+		Auditor:   auditor,
+	})
+
+	var stepUpMaxAge time.Duration
+	if maxAge := os.Getenv("STEP_UP_MAX_AGE"); maxAge != "" {
+		stepUpMaxAge, err = time.ParseDuration(maxAge)
+		if err != nil {
+			log.Fatalf("Error parsing STEP_UP_MAX_AGE: %v", err)
+		}
+	}
+	stepUp := authorizer.RequireStepUp(stepUpMaxAge, strings.Fields(os.Getenv("STEP_UP_AMR")))
+
+	// Internal services may authenticate with a client certificate when
+	// HTTPS verifies them against TLS_CLIENT_CA_FILE.
+	var tlsConfig *tls.Config
+	var certIdentities *middleware.CertIdentities
+	if path := os.Getenv("TLS_CLIENT_CA_FILE"); path != "" {
+		// Without HTTPS there is no handshake to verify certificates in.
+		if os.Getenv("TLS_CERT_FILE") == "" {
+			log.Fatal("TLS_CLIENT_CA_FILE is set but TLS_CERT_FILE is not, client certificates need HTTPS")
+		}
+
+		tlsConfig, err = middleware.NewClientCATLSConfig(path)
+		if err != nil {
+			log.Fatalf("Error loading the client CA file: %v", err)
+		}
+
+		certIdentities, err = middleware.LoadCertIdentities(os.Getenv("MTLS_IDENTITIES_FILE"))
+		if err != nil {
+			log.Fatalf("Error loading the certificate identities: %v", err)
+		}
+	}
+
+	ensureValidToken := middleware.EnsureValidToken(verifier, middleware.WithSessionCookie(sessions), middleware.WithAuditor(auditor))
+	apiKeyAuth := middleware.APIKeyAuth(models.DB, auditor)
+	clientCertAuth := middleware.ClientCertAuth(certIdentities, auditor)
+
+	// The local issuer has no Auth0 profile to read.
+	var userInfo *auth.UserInfoCache
+	if _, local := verifier.(*middleware.LocalVerifier); !local {
+		userInfo = auth.NewUserInfoCache(5 * time.Minute)
+	}
+
+	authorRepository := repository.NewGormAuthorRepository(models.DB)
+	bookRepository := repository.NewGormBookRepository(models.DB)
+	transactor := repository.NewGormTransactor(models.DB)
+	authorController := authors.NewAuthorController(service.NewAuthorService(authorRepository, bookRepository, transactor), authorizer)
+	bookController := controllers.NewBookController(service.NewBookService(bookRepository, authorRepository, transactor), authorizer)
+	apiKeyController := apikeys.NewAPIKeyController(models.DB, authorizer)
+	auditController := audit.NewAuditController(models.DB, authorizer)
 
 	docs.SwaggerInfo.BasePath = "/api/v1"
 	v1 := r.Group("/api/v1")
 	{
-		v1.POST("/login", auth.Login)
+		v1.POST("/login", auditor.AuditLogin(), middleware.LoginThrottle(attempts, middleware.DefaultUserThrottle, middleware.DefaultIPThrottle), auth.Login(sessions))
+		v1.POST("/token/refresh", auth.Refresh(sessions))
+		v1.POST("/token/client", auth.ClientToken)
+		v1.POST("/logout", auth.Logout(sessions))
+		v1.GET("/me", ensureValidToken, auth.Me(sessions, userInfo, authorizer))
+
+		stateStore := auth.NewMemoryStateStore()
+		v1.GET("/auth/authorize", auth.Authorize(stateStore))
+		v1.GET("/auth/callback", auth.Callback(stateStore, sessions))
+
+		// Only the local issuer can mint tokens, so this route answers 404
+		// when tokens come from Auth0.
+		localIssuer, _ := verifier.(*middleware.LocalVerifier)
+		v1.POST("/dev/token", dev.Token(localIssuer))
 
 		author := v1.Group("/authors")
-		author.Use(middleware.EnsureValidToken())
+		author.Use(middleware.RequireCSRF(), clientCertAuth, apiKeyAuth, ensureValidToken)
 		{
-			author.GET("", authors.Authors)
-			author.GET("/:id", authors.Author)
-			author.POST("", authors.CreateAuthor)
-			author.PATCH("/:id", authors.UpdateAuthor)
-			author.DELETE("/:id", authors.DeleteAuthor)
+			author.GET("", authorizer.RequirePermission("read:author"), authorController.Authors)
+			author.GET("/:id", authorizer.RequirePermission("read:author"), authorController.Author)
+			author.POST("", authorizer.RequirePermission("create:author"), authorController.CreateAuthor)
+			author.PATCH("/:id", authorizer.RequirePermission("update:author"), authorController.UpdateAuthor)
+			author.DELETE("/:id", authorizer.RequirePermission("delete:author"), stepUp, authorController.DeleteAuthor)
 		}
 
 		book := v1.Group("/books")
-		book.Use(middleware.EnsureValidToken())
+		book.Use(middleware.RequireCSRF(), clientCertAuth, apiKeyAuth, ensureValidToken)
 		{
-			book.GET("", controllers.Books)
-			book.GET("/:id", controllers.Book)
-			book.POST("", controllers.CreateBook)
-			book.PATCH("/:id", controllers.UpdateBook)
-			book.DELETE("/:id", controllers.DeleteBook)
+			book.GET("", authorizer.RequirePermission("read:book"), bookController.Books)
+			book.GET("/:id", authorizer.RequirePermission("read:book"), bookController.Book)
+			book.POST("", authorizer.RequirePermission("create:book"), bookController.CreateBook)
+			book.PATCH("/:id", authorizer.RequirePermission("update:book"), bookController.UpdateBook)
+			book.DELETE("/:id", authorizer.RequirePermission("delete:book"), stepUp, bookController.DeleteBook)
+		}
+
+		admin := v1.Group("/admin")
+		admin.Use(middleware.RequireCSRF(), ensureValidToken, authorizer.RequirePermission(middleware.AdminPermission))
+		{
+			admin.GET("/api-keys", apiKeyController.APIKeys)
+			admin.GET("/api-keys/:id", apiKeyController.APIKey)
+			admin.POST("/api-keys", apiKeyController.CreateAPIKey)
+			admin.PATCH("/api-keys/:id", apiKeyController.UpdateAPIKey)
+			admin.DELETE("/api-keys/:id", apiKeyController.DeleteAPIKey)
+
+			admin.GET("/audit-events", auditController.AuditEvents)
+
+			// Users live in Auth0, there are none to manage with the
+			// local issuer. They span every organization, so managing
+			// them takes more than the admin of one.
+			if _, local := verifier.(*middleware.LocalVerifier); !local {
+				mgmt := management.NewFromEnv()
+				userAdmin := admin.Group("", authorizer.RequirePermission(middleware.UserAdminPermission))
+				userAdmin.GET("/users", users.Users(mgmt))
+				userAdmin.GET("/users/:id", users.User(mgmt))
+				userAdmin.POST("/users/:id/roles", users.AssignRoles(mgmt, policyEngine))
+				userAdmin.DELETE("/users/:id/roles", users.RemoveRoles(mgmt, policyEngine))
+				userAdmin.POST("/users/:id/permissions", users.AssignPermissions(mgmt))
+				userAdmin.DELETE("/users/:id/permissions", users.RemovePermissions(mgmt))
+				userAdmin.POST("/users/:id/block", users.BlockUser(mgmt))
+				userAdmin.DELETE("/users/:id/block", users.UnblockUser(mgmt))
+				userAdmin.GET("/roles", users.Roles(mgmt, policyEngine))
+			}
 		}
 	}
 
 	r.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler,
 		ginSwagger.DefaultModelsExpandDepth(-1)))
 
-	r.Run(":" + os.Getenv("APP_PORT"))
+	addr := ":" + os.Getenv("APP_PORT")
+	if certFile := os.Getenv("TLS_CERT_FILE"); certFile != "" {
+		server := &http.Server{Addr: addr, Handler: r, TLSConfig: tlsConfig}
+		log.Fatal(server.ListenAndServeTLS(certFile, os.Getenv("TLS_KEY_FILE")))
+	}
+
+	r.Run(addr)
 }
@@ -0,0 +1,270 @@
+// Package management is a small client for the Auth0 Management API,
+// covering what we need to administer who can edit the catalog: users,
+// their roles and their permissions on our API.
+package management
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/fahmiyonda007/go-gin-gorm/client"
+)
+
+// Client calls the Management API of one Auth0 tenant with a machine to
+// machine token it obtains and renews by itself.
+type Client struct {
+	baseURL     string
+	apiAudience string
+	credentials *client.ClientCredentials
+	httpClient  *http.Client
+}
+
+// New creates a Client for the tenant at domain, see client.BaseURL.
+// apiAudience is the identifier of our API, the resource server the
+// managed permissions belong to.
+func New(domain string, clientID string, clientSecret string, apiAudience string) *Client {
+	domain = client.BaseURL(domain)
+
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+
+	return &Client{
+		baseURL:     domain + "/api/v2",
+		apiAudience: apiAudience,
+		credentials: &client.ClientCredentials{
+			TokenURL:     domain + "/oauth/token",
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Audience:     domain + "/api/v2/",
+			HTTPClient:   httpClient,
+		},
+		httpClient: httpClient,
+	}
+}
+
+// NewFromEnv creates a Client from the AUTH0_* settings. The Management
+// API needs a machine to machine application of its own; its credentials
+// go in AUTH0_MANAGEMENT_CLIENTID and AUTH0_MANAGEMENT_CLIENTSECRET. It
+// only accepts tokens for the canonical domain of the tenant, so when
+// AUTH0_DOMAIN is a custom domain, AUTH0_MANAGEMENT_DOMAIN must be set to
+// the <tenant>.auth0.com one.
+func NewFromEnv() *Client {
+	domain := os.Getenv("AUTH0_MANAGEMENT_DOMAIN")
+	if domain == "" {
+		domain = os.Getenv("AUTH0_DOMAIN")
+	}
+
+	return New(domain,
+		os.Getenv("AUTH0_MANAGEMENT_CLIENTID"),
+		os.Getenv("AUTH0_MANAGEMENT_CLIENTSECRET"),
+		os.Getenv("AUTH0_AUDIENCE"),
+	)
+}
+
+// User is the subset of an Auth0 user profile we expose.
+type User struct {
+	UserID    string `json:"user_id"`
+	Email     string `json:"email"`
+	Name      string `json:"name"`
+	Blocked   bool   `json:"blocked"`
+	LastLogin string `json:"last_login,omitempty"`
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// Role is an Auth0 role.
+type Role struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Permission is a permission of a resource server granted to a user.
+type Permission struct {
+	ResourceServerIdentifier string `json:"resource_server_identifier"`
+	PermissionName           string `json:"permission_name"`
+}
+
+// Error is the error body of the Management API.
+type Error struct {
+	StatusCode int    `json:"statusCode"`
+	Err        string `json:"error"`
+	Message    string `json:"message"`
+	ErrorCode  string `json:"errorCode"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("management api: %d %s: %s", e.StatusCode, e.Err, e.Message)
+}
+
+// ListUsers returns one page, starting at zero, of the users matching the
+// Lucene query q, which may be empty, along with the number of matches.
+func (m *Client) ListUsers(ctx context.Context, q string, page int, perPage int) ([]User, int, error) {
+	query := url.Values{
+		"page":           {strconv.Itoa(page)},
+		"per_page":       {strconv.Itoa(perPage)},
+		"include_totals": {"true"},
+	}
+	if q != "" {
+		query.Set("q", q)
+		query.Set("search_engine", "v3")
+	}
+
+	var output struct {
+		Users []User `json:"users"`
+		Total int    `json:"total"`
+	}
+	if err := m.do(ctx, http.MethodGet, "/users?"+query.Encode(), nil, &output); err != nil {
+		return nil, 0, err
+	}
+
+	return output.Users, output.Total, nil
+}
+
+// GetUser returns the user with id.
+func (m *Client) GetUser(ctx context.Context, id string) (*User, error) {
+	var user User
+	if err := m.do(ctx, http.MethodGet, "/users/"+url.PathEscape(id), nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SetBlocked blocks or unblocks the user with id. A blocked user can't
+// log in anymore.
+func (m *Client) SetBlocked(ctx context.Context, id string, blocked bool) (*User, error) {
+	var user User
+	body := map[string]bool{"blocked": blocked}
+	if err := m.do(ctx, http.MethodPatch, "/users/"+url.PathEscape(id), body, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ListRoles returns every role of the tenant.
+func (m *Client) ListRoles(ctx context.Context) ([]Role, error) {
+	var roles []Role
+	if err := m.do(ctx, http.MethodGet, "/roles", nil, &roles); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// UserRoles returns the roles assigned to the user with id.
+func (m *Client) UserRoles(ctx context.Context, id string) ([]Role, error) {
+	var roles []Role
+	if err := m.do(ctx, http.MethodGet, "/users/"+url.PathEscape(id)+"/roles", nil, &roles); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// AssignRoles adds the roles with roleIDs to the user with id.
+func (m *Client) AssignRoles(ctx context.Context, id string, roleIDs []string) error {
+	body := map[string][]string{"roles": roleIDs}
+	return m.do(ctx, http.MethodPost, "/users/"+url.PathEscape(id)+"/roles", body, nil)
+}
+
+// RemoveRoles removes the roles with roleIDs from the user with id.
+func (m *Client) RemoveRoles(ctx context.Context, id string, roleIDs []string) error {
+	body := map[string][]string{"roles": roleIDs}
+	return m.do(ctx, http.MethodDelete, "/users/"+url.PathEscape(id)+"/roles", body, nil)
+}
+
+// UserPermissions returns the names of the permissions on our API granted
+// directly to the user with id.
+func (m *Client) UserPermissions(ctx context.Context, id string) ([]string, error) {
+	var permissions []Permission
+	if err := m.do(ctx, http.MethodGet, "/users/"+url.PathEscape(id)+"/permissions", nil, &permissions); err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, permission := range permissions {
+		if permission.ResourceServerIdentifier == m.apiAudience {
+			names = append(names, permission.PermissionName)
+		}
+	}
+	return names, nil
+}
+
+// AssignPermissions grants permissions on our API to the user with id.
+func (m *Client) AssignPermissions(ctx context.Context, id string, permissions []string) error {
+	return m.do(ctx, http.MethodPost, "/users/"+url.PathEscape(id)+"/permissions", m.permissionsBody(permissions), nil)
+}
+
+// RemovePermissions revokes permissions on our API from the user with id.
+func (m *Client) RemovePermissions(ctx context.Context, id string, permissions []string) error {
+	return m.do(ctx, http.MethodDelete, "/users/"+url.PathEscape(id)+"/permissions", m.permissionsBody(permissions), nil)
+}
+
+func (m *Client) permissionsBody(permissions []string) map[string][]Permission {
+	body := make([]Permission, 0, len(permissions))
+	for _, permission := range permissions {
+		body = append(body, Permission{
+			ResourceServerIdentifier: m.apiAudience,
+			PermissionName:           permission,
+		})
+	}
+	return map[string][]Permission{"permissions": body}
+}
+
+// do sends body as JSON to the endpoint at path and decodes the answer
+// into out, which may be nil. Error statuses come back as *Error.
+func (m *Client) do(ctx context.Context, method string, path string, body interface{}, out interface{}) error {
+	token, err := m.credentials.Token(ctx)
+	if err != nil {
+		return err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, m.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := m.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		apiErr := &Error{StatusCode: res.StatusCode}
+		if err := json.Unmarshal(data, apiErr); err != nil {
+			apiErr.Err = http.StatusText(res.StatusCode)
+		}
+		apiErr.StatusCode = res.StatusCode
+		return apiErr
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,228 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testAudience = "https://books.example.com"
+
+// fakeTenant stands in for the authentication and Management APIs of an
+// Auth0 tenant. Management requests are answered by routes, keyed by
+// method and path.
+type fakeTenant struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	tokenCalls int
+	bodies     map[string]string
+	routes     map[string]http.HandlerFunc
+}
+
+func newFakeTenant(t *testing.T, routes map[string]http.HandlerFunc) *fakeTenant {
+	t.Helper()
+
+	tenant := &fakeTenant{bodies: map[string]string{}, routes: routes}
+	tenant.Server = httptest.NewServer(http.HandlerFunc(tenant.serveHTTP))
+	t.Cleanup(tenant.Close)
+
+	return tenant
+}
+
+func (f *fakeTenant) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/oauth/token" {
+		_ = r.ParseForm()
+		if r.PostForm.Get("audience") != f.URL+"/api/v2/" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		f.tokenCalls++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "management-token",
+			"expires_in":   86400,
+		})
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer management-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	key := r.Method + " " + r.URL.Path
+	body, _ := io.ReadAll(r.Body)
+	f.bodies[key] = string(body)
+
+	route, ok := f.routes[key]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"statusCode":404,"error":"Not Found","message":"The user does not exist.","errorCode":"inexistent_user"}`))
+		return
+	}
+	route(w, r)
+}
+
+func respond(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	var query string
+	tenant := newFakeTenant(t, map[string]http.HandlerFunc{
+		"GET /api/v2/users": func(w http.ResponseWriter, r *http.Request) {
+			query = r.URL.RawQuery
+			respond(`{"users":[{"user_id":"auth0|alice","email":"alice@example.com","blocked":false}],"total":11}`)(w, r)
+		},
+	})
+	m := New(tenant.URL, "id", "secret", testAudience)
+
+	users, total, err := m.ListUsers(context.Background(), `email:"alice@example.com"`, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if total != 11 || len(users) != 1 || users[0].UserID != "auth0|alice" {
+		t.Errorf("ListUsers = %+v, %d", users, total)
+	}
+	for _, want := range []string{"page=1", "per_page=10", "include_totals=true", "search_engine=v3", "q=email"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query %q lacks %s", query, want)
+		}
+	}
+}
+
+func TestNewFromEnvPrefersManagementDomain(t *testing.T) {
+	tenant := newFakeTenant(t, map[string]http.HandlerFunc{
+		"GET /api/v2/roles": respond(`[{"id":"rol_1","name":"editor"}]`),
+	})
+	// The custom domain serves logins, not the Management API.
+	customRequests := 0
+	custom := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		customRequests++
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	t.Cleanup(custom.Close)
+
+	t.Setenv("AUTH0_DOMAIN", custom.URL)
+	t.Setenv("AUTH0_MANAGEMENT_DOMAIN", tenant.URL)
+	if _, err := NewFromEnv().ListRoles(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if customRequests != 0 {
+		t.Errorf("%d requests went to AUTH0_DOMAIN, want none", customRequests)
+	}
+
+	t.Setenv("AUTH0_DOMAIN", tenant.URL)
+	t.Setenv("AUTH0_MANAGEMENT_DOMAIN", "")
+	if _, err := NewFromEnv().ListRoles(context.Background()); err != nil {
+		t.Errorf("without AUTH0_MANAGEMENT_DOMAIN: %v", err)
+	}
+}
+
+func TestTokenIsReused(t *testing.T) {
+	tenant := newFakeTenant(t, map[string]http.HandlerFunc{
+		"GET /api/v2/roles": respond(`[{"id":"rol_1","name":"editor"}]`),
+	})
+	m := New(tenant.URL, "id", "secret", testAudience)
+
+	for i := 0; i < 3; i++ {
+		if _, err := m.ListRoles(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if tenant.tokenCalls != 1 {
+		t.Errorf("token endpoint called %d times, want 1", tenant.tokenCalls)
+	}
+}
+
+func TestUserPermissionsKeepsOurAPI(t *testing.T) {
+	tenant := newFakeTenant(t, map[string]http.HandlerFunc{
+		"GET /api/v2/users/auth0|alice/permissions": respond(`[
+			{"resource_server_identifier":"` + testAudience + `","permission_name":"read:book"},
+			{"resource_server_identifier":"https://other.example.com","permission_name":"read:invoice"}
+		]`),
+	})
+	m := New(tenant.URL, "id", "secret", testAudience)
+
+	permissions, err := m.UserPermissions(context.Background(), "auth0|alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(permissions) != 1 || permissions[0] != "read:book" {
+		t.Errorf("permissions = %v, want [read:book]", permissions)
+	}
+}
+
+func TestWrites(t *testing.T) {
+	tenant := newFakeTenant(t, map[string]http.HandlerFunc{
+		"POST /api/v2/users/auth0|alice/roles":         respond(``),
+		"DELETE /api/v2/users/auth0|alice/permissions": respond(``),
+		"PATCH /api/v2/users/auth0|alice":              respond(`{"user_id":"auth0|alice","blocked":true}`),
+	})
+	m := New(tenant.URL, "id", "secret", testAudience)
+	ctx := context.Background()
+
+	if err := m.AssignRoles(ctx, "auth0|alice", []string{"rol_1"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := tenant.bodies["POST /api/v2/users/auth0|alice/roles"]; got != `{"roles":["rol_1"]}` {
+		t.Errorf("roles body = %s", got)
+	}
+
+	if err := m.RemovePermissions(ctx, "auth0|alice", []string{"delete:book"}); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"permissions":[{"resource_server_identifier":"` + testAudience + `","permission_name":"delete:book"}]}`
+	if got := tenant.bodies["DELETE /api/v2/users/auth0|alice/permissions"]; got != want {
+		t.Errorf("permissions body = %s, want %s", got, want)
+	}
+
+	user, err := m.SetBlocked(ctx, "auth0|alice", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.Blocked || tenant.bodies["PATCH /api/v2/users/auth0|alice"] != `{"blocked":true}` {
+		t.Errorf("SetBlocked = %+v, body %s", user, tenant.bodies["PATCH /api/v2/users/auth0|alice"])
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tenant := newFakeTenant(t, map[string]http.HandlerFunc{
+		"GET /api/v2/roles": func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`<html>upstream down</html>`))
+		},
+	})
+	m := New(tenant.URL, "id", "secret", testAudience)
+
+	var apiErr *Error
+	_, err := m.GetUser(context.Background(), "auth0|nobody")
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Message != "The user does not exist." {
+		t.Errorf("GetUser error = %v, want the decoded 404", err)
+	}
+
+	_, err = m.ListRoles(context.Background())
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || apiErr.Err != "Service Unavailable" {
+		t.Errorf("ListRoles error = %v, want a 503", err)
+	}
+
+	refused := New(tenant.URL, "id", "secret", testAudience)
+	refused.credentials.Audience = "https://wrong.example.com/"
+	if _, err := refused.ListRoles(context.Background()); err == nil || errors.As(err, &apiErr) {
+		t.Errorf("ListRoles with refused credentials = %v, want a token error", err)
+	}
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fahmiyonda007/go-gin-gorm/migrations"
+	"github.com/fahmiyonda007/go-gin-gorm/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// openAuditDB opens a migrated in-memory database for audit events.
+func openAuditDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := models.Open(models.DatabaseConfig{Driver: "sqlite", Name: ":memory:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestAuditedIPTrustsOnlyConfiguredProxies(t *testing.T) {
+	verifier, err := NewLocalVerifier("http://localhost:8080/", testAudience, newRSAKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		proxies []string
+		want    string
+	}{
+		{"no trusted proxy", nil, "192.0.2.1"},
+		{"from a trusted proxy", []string{"192.0.2.1"}, "203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openAuditDB(t)
+			auditor := NewAuditor(db)
+			authorizer := NewAuthorizer(AuthorizerConfig{Auditor: auditor})
+
+			r := gin.New()
+			if err := r.SetTrustedProxies(tt.proxies); err != nil {
+				t.Fatal(err)
+			}
+			r.GET("/token", EnsureValidToken(verifier, WithAuditor(auditor)))
+			r.GET("/denied", func(c *gin.Context) {
+				SetClaims(c, "auth0|alice", CustomClaims{})
+			}, authorizer.RequirePermission("read:book"))
+
+			for _, path := range []string{"/token", "/denied"} {
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				req.RemoteAddr = "192.0.2.1:1234"
+				req.Header.Set("Authorization", "Bearer forged")
+				req.Header.Set("X-Forwarded-For", "203.0.113.7")
+				r.ServeHTTP(httptest.NewRecorder(), req)
+			}
+
+			var events []models.AuditEvent
+			if err := db.Order("id").Find(&events).Error; err != nil {
+				t.Fatal(err)
+			}
+			if len(events) != 2 || events[0].Type != models.AuditTokenRejected || events[1].Type != models.AuditPermissionDenied {
+				t.Fatalf("events = %+v, want a rejected token and a denial", events)
+			}
+			for _, event := range events {
+				if event.IP != tt.want {
+					t.Errorf("%s event IP = %q, want %q", event.Type, event.IP, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestAuditorsRecordInTheirOwnDatabase(t *testing.T) {
+	first, second := openAuditDB(t), openAuditDB(t)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		SetClaims(c, "auth0|alice", CustomClaims{})
+	})
+	r.GET("/first", NewAuthorizer(AuthorizerConfig{Auditor: NewAuditor(first)}).RequirePermission("read:book"))
+	r.GET("/second", NewAuthorizer(AuthorizerConfig{Auditor: NewAuditor(second)}).RequirePermission("read:author"))
+
+	for _, path := range []string{"/first", "/second"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	for db, want := range map[*gorm.DB]string{first: "read:book", second: "read:author"} {
+		var events []models.AuditEvent
+		if err := db.Find(&events).Error; err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 1 || events[0].Permission != want {
+			t.Errorf("events = %+v, want only the denial of %s", events, want)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+)
+
+// IntrospectionVerifier validates opaque tokens by asking the issuer
+// about them through an RFC 7662 introspection endpoint. Active tokens
+// are cached until they expire so each one is introspected only once.
+type IntrospectionVerifier struct {
+	endpoint     string
+	clientID     string
+	clientSecret string
+	audience     string
+	httpClient   *http.Client
+
+	mu    sync.Mutex
+	cache map[string]*validator.ValidatedClaims
+}
+
+// NewIntrospectionVerifier creates an IntrospectionVerifier that
+// authenticates to endpoint with clientID and clientSecret. When audience
+// is set, tokens introspected for another audience, or for none, are
+// rejected.
+func NewIntrospectionVerifier(endpoint string, clientID string, clientSecret string, audience string) *IntrospectionVerifier {
+	return &IntrospectionVerifier{
+		endpoint:     endpoint,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		audience:     audience,
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+		cache:        map[string]*validator.ValidatedClaims{},
+	}
+}
+
+// introspection is the answer of the endpoint. Besides the standard
+// members it reads the same custom claims our JWTs carry.
+type introspection struct {
+	Active    bool     `json:"active"`
+	Scope     string   `json:"scope"`
+	ClientID  string   `json:"client_id"`
+	Sub       string   `json:"sub"`
+	Audience  audience `json:"aud"`
+	Issuer    string   `json:"iss"`
+	Expiry    int64    `json:"exp"`
+	IssuedAt  int64    `json:"iat"`
+	NotBefore int64    `json:"nbf"`
+	ID        string   `json:"jti"`
+	CustomClaims
+}
+
+// audience is a single string or an array of them.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// ValidateToken satisfies TokenVerifier.
+func (v *IntrospectionVerifier) ValidateToken(ctx context.Context, token string) (interface{}, error) {
+	sum := sha256.Sum256([]byte(token))
+	key := hex.EncodeToString(sum[:])
+	now := time.Now()
+
+	v.mu.Lock()
+	claims, ok := v.cache[key]
+	v.mu.Unlock()
+	if ok && now.Before(time.Unix(claims.RegisteredClaims.Expiry, 0)) {
+		return claims, nil
+	}
+
+	result, err := v.introspect(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if !result.Active {
+		return nil, errors.New("token is not active")
+	}
+	if result.Expiry != 0 && !now.Before(time.Unix(result.Expiry, 0)) {
+		return nil, errors.New("token is expired")
+	}
+	if v.audience != "" && !contains(result.Audience, v.audience) {
+		return nil, fmt.Errorf("token is not meant for audience %q", v.audience)
+	}
+
+	// Client credentials tokens may not have a subject.
+	subject := result.Sub
+	if subject == "" {
+		subject = result.ClientID
+	}
+
+	customClaims := result.CustomClaims
+	customClaims.Scope = result.Scope
+	claims = &validator.ValidatedClaims{
+		RegisteredClaims: validator.RegisteredClaims{
+			Issuer:    result.Issuer,
+			Subject:   subject,
+			Audience:  result.Audience,
+			Expiry:    result.Expiry,
+			NotBefore: result.NotBefore,
+			IssuedAt:  result.IssuedAt,
+			ID:        result.ID,
+		},
+		CustomClaims: &customClaims,
+	}
+
+	// Without exp there is no telling how long the answer holds.
+	if result.Expiry != 0 {
+		v.mu.Lock()
+		for cachedKey, cached := range v.cache {
+			if !now.Before(time.Unix(cached.RegisteredClaims.Expiry, 0)) {
+				delete(v.cache, cachedKey)
+			}
+		}
+		v.cache[key] = claims
+		v.mu.Unlock()
+	}
+
+	return claims, nil
+}
+
+func (v *IntrospectionVerifier) introspect(ctx context.Context, token string) (*introspection, error) {
+	form := url.Values{
+		"token":           {token},
+		"token_type_hint": {"access_token"},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(v.clientID), url.QueryEscape(v.clientSecret))
+
+	res, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not introspect the token: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("introspection endpoint answered %s", res.Status)
+	}
+
+	var result introspection
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("could not decode the introspection response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// OpaqueTokenVerifier validates JWTs with one verifier and hands every
+// other token to an introspection verifier.
+type OpaqueTokenVerifier struct {
+	JWT    TokenVerifier
+	Opaque TokenVerifier
+}
+
+// ValidateToken satisfies TokenVerifier.
+func (v *OpaqueTokenVerifier) ValidateToken(ctx context.Context, token string) (interface{}, error) {
+	if strings.Count(token, ".") == 2 {
+		return v.JWT.ValidateToken(ctx, token)
+	}
+	return v.Opaque.ValidateToken(ctx, token)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,237 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+)
+
+// introspectionEndpoint answers RFC 7662 requests authenticated as
+// client-id with the response registered for the token, and counts the
+// requests per token.
+type introspectionEndpoint struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	responses map[string]map[string]interface{}
+	requests  map[string]int
+}
+
+func newIntrospectionEndpoint(t *testing.T) *introspectionEndpoint {
+	t.Helper()
+
+	e := &introspectionEndpoint{responses: map[string]map[string]interface{}{}, requests: map[string]int{}}
+	e.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, ok := r.BasicAuth(); !ok || id != "client-id" || secret != "client-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = r.ParseForm()
+		token := r.PostForm.Get("token")
+
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		e.requests[token]++
+		response, ok := e.responses[token]
+		if !ok {
+			response = map[string]interface{}{"active": false}
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(e.Close)
+
+	return e
+}
+
+func (e *introspectionEndpoint) answer(token string, response map[string]interface{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.responses[token] = response
+}
+
+func (e *introspectionEndpoint) count(token string) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.requests[token]
+}
+
+func activeResponse(expiry time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"active":      true,
+		"sub":         "partner|acme",
+		"aud":         testAudience,
+		"scope":       "read:book",
+		"exp":         expiry.Unix(),
+		"permissions": []string{"read:book"},
+		"org_id":      "o1",
+	}
+}
+
+func TestIntrospectionVerifier(t *testing.T) {
+	endpoint := newIntrospectionEndpoint(t)
+	verifier := NewIntrospectionVerifier(endpoint.URL, "client-id", "client-secret", "")
+	ctx := context.Background()
+
+	endpoint.answer("active", activeResponse(time.Now().Add(time.Hour)))
+	endpoint.answer("expired", activeResponse(time.Now().Add(-time.Minute)))
+	noExpiry := activeResponse(time.Now())
+	delete(noExpiry, "exp")
+	endpoint.answer("no-expiry", noExpiry)
+	endpoint.answer("client", map[string]interface{}{"active": true, "client_id": "m2m-client", "exp": time.Now().Add(time.Hour).Unix()})
+
+	t.Run("active", func(t *testing.T) {
+		claims, err := verifier.ValidateToken(ctx, "active")
+		if err != nil {
+			t.Fatal(err)
+		}
+		validated := claims.(*validator.ValidatedClaims)
+		customClaims := validated.CustomClaims.(*CustomClaims)
+		if validated.RegisteredClaims.Subject != "partner|acme" || customClaims.OrgID != "o1" ||
+			customClaims.Scope != "read:book" || len(customClaims.Permissions) != 1 {
+			t.Errorf("claims = %+v, %+v", validated.RegisteredClaims, customClaims)
+		}
+	})
+
+	t.Run("cached until it expires", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			if _, err := verifier.ValidateToken(ctx, "active"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if n := endpoint.count("active"); n != 1 {
+			t.Errorf("introspected %d times, want 1", n)
+		}
+	})
+
+	t.Run("inactive", func(t *testing.T) {
+		if _, err := verifier.ValidateToken(ctx, "revoked"); err == nil {
+			t.Error("inactive token accepted")
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		if _, err := verifier.ValidateToken(ctx, "expired"); err == nil {
+			t.Error("expired token accepted")
+		}
+	})
+
+	t.Run("not cached without exp", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			if _, err := verifier.ValidateToken(ctx, "no-expiry"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if n := endpoint.count("no-expiry"); n != 2 {
+			t.Errorf("introspected %d times, want 2", n)
+		}
+	})
+
+	t.Run("client credentials token", func(t *testing.T) {
+		claims, err := verifier.ValidateToken(ctx, "client")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sub := claims.(*validator.ValidatedClaims).RegisteredClaims.Subject; sub != "m2m-client" {
+			t.Errorf("subject = %q, want the client id", sub)
+		}
+	})
+
+	t.Run("wrong client credentials", func(t *testing.T) {
+		wrong := NewIntrospectionVerifier(endpoint.URL, "client-id", "wrong", "")
+		if _, err := wrong.ValidateToken(ctx, "active"); err == nil {
+			t.Error("token accepted although introspection failed")
+		}
+	})
+}
+
+func TestIntrospectionVerifierCacheEndsAtExpiry(t *testing.T) {
+	endpoint := newIntrospectionEndpoint(t)
+	verifier := NewIntrospectionVerifier(endpoint.URL, "client-id", "client-secret", "")
+	ctx := context.Background()
+
+	expiry := time.Now().Add(time.Second)
+	endpoint.answer("short", activeResponse(expiry))
+	if _, err := verifier.ValidateToken(ctx, "short"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once exp passes the cached answer no longer holds, the endpoint is
+	// asked again and now says the token is gone.
+	endpoint.answer("short", map[string]interface{}{"active": false})
+	time.Sleep(time.Until(time.Unix(expiry.Unix(), 0)) + 10*time.Millisecond)
+
+	if _, err := verifier.ValidateToken(ctx, "short"); err == nil {
+		t.Error("token accepted from the cache after it expired")
+	}
+	if n := endpoint.count("short"); n != 2 {
+		t.Errorf("introspected %d times, want 2", n)
+	}
+}
+
+func TestIntrospectionVerifierAudience(t *testing.T) {
+	endpoint := newIntrospectionEndpoint(t)
+	verifier := NewIntrospectionVerifier(endpoint.URL, "client-id", "client-secret", testAudience)
+	expiry := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		audience interface{}
+		valid    bool
+	}{
+		{"ours", testAudience, true},
+		{"ours among others", []string{"https://other.example.com", testAudience}, true},
+		{"another", "https://other.example.com", false},
+		{"none", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := activeResponse(expiry)
+			if tt.audience == nil {
+				delete(response, "aud")
+			} else {
+				response["aud"] = tt.audience
+			}
+			endpoint.answer(tt.name, response)
+
+			_, err := verifier.ValidateToken(context.Background(), tt.name)
+			if (err == nil) != tt.valid {
+				t.Errorf("ValidateToken() error = %v, want valid %t", err, tt.valid)
+			}
+		})
+	}
+}
+
+// recordingVerifier remembers the tokens it was asked to validate.
+type recordingVerifier struct {
+	tokens []string
+}
+
+func (v *recordingVerifier) ValidateToken(ctx context.Context, token string) (interface{}, error) {
+	v.tokens = append(v.tokens, token)
+	return &validator.ValidatedClaims{}, nil
+}
+
+func TestOpaqueTokenVerifierRouting(t *testing.T) {
+	jwtVerifier, opaqueVerifier := &recordingVerifier{}, &recordingVerifier{}
+	verifier := &OpaqueTokenVerifier{JWT: jwtVerifier, Opaque: opaqueVerifier}
+
+	for _, token := range []string{"header.payload.signature", "opaque-token", "a.b"} {
+		if _, err := verifier.ValidateToken(context.Background(), token); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(jwtVerifier.tokens) != 1 || jwtVerifier.tokens[0] != "header.payload.signature" {
+		t.Errorf("JWT verifier got %v, want only the JWT", jwtVerifier.tokens)
+	}
+	if len(opaqueVerifier.tokens) != 2 {
+		t.Errorf("opaque verifier got %v, want the two other tokens", opaqueVerifier.tokens)
+	}
+}
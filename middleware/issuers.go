@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/auth0/go-jwt-middleware/v2/jwks"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// TrustedIssuer is an issuer whose tokens we accept when they are meant
+// for Audience.
+type TrustedIssuer struct {
+	Issuer   string
+	Audience string
+}
+
+// IssuerConfig configures NewIssuerVerifier.
+type IssuerConfig struct {
+	Issuers []TrustedIssuer
+	// ClockSkew is how far exp, nbf and iat may be off, zero by default.
+	ClockSkew time.Duration
+	// Algorithms a token may be signed with, RS256 when empty.
+	Algorithms []validator.SignatureAlgorithm
+}
+
+// IssuerVerifier validates tokens from several issuers. The token's iss
+// picks the issuer, whose signing keys come from its own caching JWKS
+// provider.
+type IssuerVerifier struct {
+	// validators holds, per issuer and algorithm, one validator for each
+	// trusted audience.
+	validators map[string]map[string][]*validator.Validator
+}
+
+// NewIssuerVerifier creates an IssuerVerifier for config.
+func NewIssuerVerifier(config IssuerConfig) (*IssuerVerifier, error) {
+	if len(config.Issuers) == 0 {
+		return nil, errors.New("no trusted issuer configured")
+	}
+
+	algorithms := config.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = []validator.SignatureAlgorithm{validator.RS256}
+	}
+
+	v := &IssuerVerifier{validators: map[string]map[string][]*validator.Validator{}}
+	providers := map[string]*jwks.CachingProvider{}
+
+	for _, trusted := range config.Issuers {
+		provider, ok := providers[trusted.Issuer]
+		if !ok {
+			issuerURL, err := url.Parse(trusted.Issuer)
+			if err != nil {
+				return nil, err
+			}
+			provider = jwks.NewCachingProvider(issuerURL, time.Duration(5*time.Minute))
+			providers[trusted.Issuer] = provider
+			v.validators[trusted.Issuer] = map[string][]*validator.Validator{}
+		}
+
+		for _, algorithm := range algorithms {
+			jwtValidator, err := validator.New(provider.KeyFunc,
+				algorithm,
+				trusted.Issuer,
+				[]string{trusted.Audience},
+				validator.WithCustomClaims(newCustomClaims),
+				validator.WithAllowedClockSkew(config.ClockSkew),
+			)
+			if err != nil {
+				return nil, fmt.Errorf("issuer %s: %w", trusted.Issuer, err)
+			}
+
+			byAlgorithm := v.validators[trusted.Issuer]
+			byAlgorithm[string(algorithm)] = append(byAlgorithm[string(algorithm)], jwtValidator)
+		}
+	}
+
+	return v, nil
+}
+
+// NewIssuerConfigFromEnv reads the trusted issuers from AUTH0_DOMAIN and
+// AUTH0_AUDIENCE, plus any listed in AUTH0_TRUSTED_ISSUERS, along with
+// AUTH0_CLOCK_SKEW and AUTH0_ALGORITHMS.
+//
+// AUTH0_TRUSTED_ISSUERS is a space separated list of issuer|audience
+// pairs. The audience defaults to AUTH0_AUDIENCE and an issuer without a
+// scheme is taken as an Auth0 domain, like AUTH0_DOMAIN.
+func NewIssuerConfigFromEnv() (IssuerConfig, error) {
+	var config IssuerConfig
+	audience := os.Getenv("AUTH0_AUDIENCE")
+
+	if domain := os.Getenv("AUTH0_DOMAIN"); domain != "" {
+		config.Issuers = append(config.Issuers, TrustedIssuer{
+			Issuer:   issuerURL(domain),
+			Audience: audience,
+		})
+	}
+
+	for _, entry := range strings.Fields(os.Getenv("AUTH0_TRUSTED_ISSUERS")) {
+		issuer, entryAudience, found := strings.Cut(entry, "|")
+		if !found || entryAudience == "" {
+			entryAudience = audience
+		}
+		config.Issuers = append(config.Issuers, TrustedIssuer{
+			Issuer:   issuerURL(issuer),
+			Audience: entryAudience,
+		})
+	}
+
+	if skew := os.Getenv("AUTH0_CLOCK_SKEW"); skew != "" {
+		duration, err := time.ParseDuration(skew)
+		if err != nil {
+			return config, fmt.Errorf("invalid AUTH0_CLOCK_SKEW: %w", err)
+		}
+		config.ClockSkew = duration
+	}
+
+	for _, algorithm := range strings.Split(os.Getenv("AUTH0_ALGORITHMS"), ",") {
+		if algorithm = strings.TrimSpace(algorithm); algorithm != "" {
+			config.Algorithms = append(config.Algorithms, validator.SignatureAlgorithm(algorithm))
+		}
+	}
+
+	return config, nil
+}
+
+// issuerURL turns an Auth0 domain into the issuer its tokens carry and
+// leaves full URLs as they are.
+func issuerURL(issuer string) string {
+	if strings.Contains(issuer, "://") {
+		return issuer
+	}
+	return "https://" + strings.TrimSuffix(issuer, "/") + "/"
+}
+
+// ValidateToken satisfies TokenVerifier.
+func (v *IssuerVerifier) ValidateToken(ctx context.Context, token string) (interface{}, error) {
+	parsed, err := jwt.ParseSigned(token)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the token: %w", err)
+	}
+
+	// The claims are only peeked at to pick the validator, which checks
+	// the signature before trusting any of them.
+	var claims jwt.Claims
+	if err := parsed.UnsafeClaimsWithoutVerification(&claims); err != nil {
+		return nil, fmt.Errorf("could not parse the token: %w", err)
+	}
+
+	byAlgorithm, ok := v.validators[claims.Issuer]
+	if !ok {
+		return nil, fmt.Errorf("untrusted issuer %q", claims.Issuer)
+	}
+
+	validators, ok := byAlgorithm[parsed.Headers[0].Algorithm]
+	if !ok {
+		return nil, fmt.Errorf("signing algorithm %q is not allowed", parsed.Headers[0].Algorithm)
+	}
+
+	for _, jwtValidator := range validators {
+		validated, validateErr := jwtValidator.ValidateToken(ctx, token)
+		if validateErr == nil {
+			return validated, nil
+		}
+		err = validateErr
+	}
+
+	return nil, err
+}
@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func TestIssuerVerifierPicksIssuer(t *testing.T) {
+	keyA, keyB := newRSAKey(t), newRSAKey(t)
+	serverA := newJWKSServer(t, keyA, "key-a")
+	serverB := newJWKSServer(t, keyB, "key-b")
+
+	verifier, err := NewIssuerVerifier(IssuerConfig{Issuers: []TrustedIssuer{
+		{Issuer: serverA.URL + "/", Audience: testAudience},
+		{Issuer: serverB.URL + "/", Audience: testAudience},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"first issuer", signToken(t, keyA, "key-a", tokenClaims(serverA.URL+"/")), true},
+		{"second issuer", signToken(t, keyB, "key-b", tokenClaims(serverB.URL+"/")), true},
+		{"signed by the other issuer's key", signToken(t, keyA, "key-b", tokenClaims(serverB.URL+"/")), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verifier.ValidateToken(context.Background(), tt.token)
+			if (err == nil) != tt.valid {
+				t.Errorf("ValidateToken() error = %v, want valid %t", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestIssuerVerifierWithTwoAudiences(t *testing.T) {
+	key := newRSAKey(t)
+	server := newJWKSServer(t, key, "key-1")
+	issuer := server.URL + "/"
+
+	verifier, err := NewIssuerVerifier(IssuerConfig{Issuers: []TrustedIssuer{
+		{Issuer: issuer, Audience: testAudience},
+		{Issuer: issuer, Audience: "https://partners.example.com"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for audience, valid := range map[string]bool{
+		testAudience:                   true,
+		"https://partners.example.com": true,
+		"https://other.example.com":    false,
+	} {
+		claims := tokenClaims(issuer)
+		claims["aud"] = audience
+
+		_, err := verifier.ValidateToken(context.Background(), signToken(t, key, "key-1", claims))
+		if (err == nil) != valid {
+			t.Errorf("audience %s: error = %v, want valid %t", audience, err, valid)
+		}
+	}
+}
+
+func TestIssuerVerifierRejectsDisallowedAlgorithm(t *testing.T) {
+	key := newRSAKey(t)
+	server := newJWKSServer(t, key, "key-1")
+	issuer := server.URL + "/"
+
+	verifier, err := NewIssuerVerifier(IssuerConfig{Issuers: []TrustedIssuer{{Issuer: issuer, Audience: testAudience}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An HMAC token keyed with something public must never pass for RS256.
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, tokenClaims(issuer, "admin"))
+	token.Header["kid"] = "key-1"
+	signed, err := token.SignedString([]byte("public"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := verifier.ValidateToken(context.Background(), signed); err == nil {
+		t.Error("HS256 token accepted")
+	}
+
+	rs384 := jwt.NewWithClaims(jwt.SigningMethodRS384, tokenClaims(issuer))
+	rs384.Header["kid"] = "key-1"
+	signed, err = rs384.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.ValidateToken(context.Background(), signed); err == nil {
+		t.Error("RS384 token accepted without being configured")
+	}
+
+	allowing, err := NewIssuerVerifier(IssuerConfig{
+		Issuers:    []TrustedIssuer{{Issuer: issuer, Audience: testAudience}},
+		Algorithms: []validator.SignatureAlgorithm{validator.RS256, validator.RS384},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := allowing.ValidateToken(context.Background(), signed); err != nil {
+		t.Errorf("RS384 token rejected once configured: %v", err)
+	}
+}
+
+func TestIssuerVerifierClockSkew(t *testing.T) {
+	key := newRSAKey(t)
+	server := newJWKSServer(t, key, "key-1")
+	issuer := server.URL + "/"
+
+	claims := tokenClaims(issuer)
+	claims["exp"] = time.Now().Add(-10 * time.Second).Unix()
+	token := signToken(t, key, "key-1", claims)
+
+	for skew, valid := range map[time.Duration]bool{0: false, 30 * time.Second: true} {
+		verifier, err := NewIssuerVerifier(IssuerConfig{
+			Issuers:   []TrustedIssuer{{Issuer: issuer, Audience: testAudience}},
+			ClockSkew: skew,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = verifier.ValidateToken(context.Background(), token)
+		if (err == nil) != valid {
+			t.Errorf("skew %s: error = %v, want valid %t", skew, err, valid)
+		}
+	}
+}
+
+func TestNewIssuerConfigFromEnv(t *testing.T) {
+	t.Setenv("AUTH0_DOMAIN", "tenant.auth0.com")
+	t.Setenv("AUTH0_AUDIENCE", testAudience)
+	t.Setenv("AUTH0_TRUSTED_ISSUERS", "https://login.example.com/|https://partners.example.com  other.eu.auth0.com  https://third.example.com/|")
+	t.Setenv("AUTH0_CLOCK_SKEW", "30s")
+	t.Setenv("AUTH0_ALGORITHMS", "RS256, PS256")
+
+	config, err := NewIssuerConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := IssuerConfig{
+		Issuers: []TrustedIssuer{
+			{Issuer: "https://tenant.auth0.com/", Audience: testAudience},
+			{Issuer: "https://login.example.com/", Audience: "https://partners.example.com"},
+			{Issuer: "https://other.eu.auth0.com/", Audience: testAudience},
+			{Issuer: "https://third.example.com/", Audience: testAudience},
+		},
+		ClockSkew:  30 * time.Second,
+		Algorithms: []validator.SignatureAlgorithm{validator.RS256, validator.PS256},
+	}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("config = %+v, want %+v", config, want)
+	}
+
+	t.Setenv("AUTH0_CLOCK_SKEW", "soon")
+	if _, err := NewIssuerConfigFromEnv(); err == nil {
+		t.Error("invalid AUTH0_CLOCK_SKEW accepted")
+	}
+}
@@ -2,23 +2,31 @@ package middleware
 
 import (
 	"context"
-	"log"
-	"net/url"
-	"os"
+	"encoding/json"
+	"errors"
+	"net/http"
 	"strings"
-	"time"
 
 	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
-	"github.com/auth0/go-jwt-middleware/v2/jwks"
 	"github.com/auth0/go-jwt-middleware/v2/validator"
 	"github.com/gin-gonic/gin"
 	adapter "github.com/gwatts/gin-adapter"
-	"github.com/joho/godotenv"
 )
 
 // CustomClaims contains custom data we want from the token.
 type CustomClaims struct {
-	Scope string `json:"scope"`
+	Scope       string   `json:"scope,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	Roles       []string `json:"roles,omitempty"`
+	OrgID       string   `json:"org_id,omitempty"`
+	// AuthTime is when the user last actively authenticated, in seconds
+	// since the epoch, and AMR how, such as "pwd" or "mfa". Auth0 only puts
+	// them in access tokens through an Action.
+	AuthTime int64    `json:"auth_time,omitempty"`
+	AMR      []string `json:"amr,omitempty"`
+	// Gty is the grant the token was issued for, "client-credentials" for
+	// machine to machine tokens.
+	Gty string `json:"gty,omitempty"`
 }
 
 // Validate does nothing for this example, but we need
@@ -27,27 +35,86 @@ func (c CustomClaims) Validate(ctx context.Context) error {
 	return nil
 }
 
-// EnsureValidToken is a middleware that will check the validity of our JWT.
-func EnsureValidToken() gin.HandlerFunc {
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env file")
+// Option configures EnsureValidToken.
+type Option func(*options)
+
+type options struct {
+	middleware []jwtmiddleware.Option
+	auditor    *Auditor
+}
+
+// WithAuditor makes EnsureValidToken record the tokens it rejects with
+// auditor.
+func WithAuditor(auditor *Auditor) Option {
+	return func(o *options) {
+		o.auditor = auditor
 	}
+}
+
+// EnsureValidToken is a middleware that will check the validity of our JWT
+// with the given verifier.
+func EnsureValidToken(verifier TokenVerifier, opts ...Option) gin.HandlerFunc {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.middleware = append(o.middleware, jwtmiddleware.WithErrorHandler(errorHandler(o.auditor)))
+
+	jwtMiddleware := jwtmiddleware.New(verifier.ValidateToken, o.middleware...)
+	checkJWT := adapter.Wrap(jwtMiddleware.CheckJWT)
+
+	return func(c *gin.Context) {
+		// Another credential, such as an API key, already authenticated
+		// the request.
+		if _, _, err := GetClaims(c); err == nil {
+			c.Next()
+			return
+		}
+
+		withClientIP(c)
+		checkJWT(c)
+	}
+}
+
+func newCustomClaims() validator.CustomClaims {
+	return &CustomClaims{}
+}
 
-	issuerURL, _ := url.Parse("https://" + os.Getenv("AUTH0_DOMAIN") + "/")
-	audience := os.Getenv("AUTH0_AUDIENCE")
+// errorHandler answers a missing or invalid JWT with the same body
+// AbortWithError writes for permission failures, and records it with
+// auditor.
+func errorHandler(auditor *Auditor) jwtmiddleware.ErrorHandler {
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		message := "JWT is invalid."
+		if errors.Is(err, jwtmiddleware.ErrJWTMissing) {
+			message = "JWT is missing."
+		}
+		auditor.rejectedRequest(r, err.Error())
 
-	provider := jwks.NewCachingProvider(issuerURL, time.Duration(5*time.Minute))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(gin.H{
+			"code":  http.StatusUnauthorized,
+			"error": message,
+		})
+	}
+}
 
-	jwtValidator, _ := validator.New(provider.KeyFunc,
-		validator.RS256,
-		issuerURL.String(),
-		[]string{audience},
-	)
+// GetClaims returns the claims that EnsureValidToken validated for the
+// current request. It fails when the request never went through the
+// middleware, so handlers can't be tricked into trusting a raw header.
+func GetClaims(c *gin.Context) (*validator.ValidatedClaims, *CustomClaims, error) {
+	claims, ok := c.Request.Context().Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	if !ok {
+		return nil, nil, errors.New("Token has not been validated")
+	}
 
-	jwtMiddleware := jwtmiddleware.New(jwtValidator.ValidateToken)
+	customClaims, ok := claims.CustomClaims.(*CustomClaims)
+	if !ok {
+		return nil, nil, errors.New("Token is missing custom claims")
+	}
 
-	return adapter.Wrap(jwtMiddleware.CheckJWT)
+	return claims, customClaims, nil
 }
 
 // HasScope checks whether our claims have a specific scope.
@@ -61,3 +128,14 @@ func (c CustomClaims) HasScope(expectedScope string) bool {
 
 	return false
 }
+
+// HasPermission checks whether our claims have a specific permission.
+func (c CustomClaims) HasPermission(expectedPermission string) bool {
+	for i := range c.Permissions {
+		if c.Permissions[i] == expectedPermission {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,195 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"gopkg.in/square/go-jose.v2"
+)
+
+const testAudience = "https://books.example.com"
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newJWKSServer serves the OpenID configuration and JWKS of an issuer
+// whose tokens are signed with key under kid.
+func newJWKSServer(t *testing.T, key *rsa.PrivateKey, kid string) *httptest.Server {
+	t.Helper()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/openid-configuration"):
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"issuer":   server.URL + "/",
+				"jwks_uri": server.URL + "/.well-known/jwks.json",
+			})
+		case strings.HasSuffix(r.URL.Path, "/jwks.json"):
+			_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{
+				Key:       &key.PublicKey,
+				KeyID:     kid,
+				Algorithm: "RS256",
+				Use:       "sig",
+			}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func tokenClaims(issuer string, permissions ...string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":         issuer,
+		"aud":         testAudience,
+		"sub":         "auth0|alice",
+		"iat":         now.Unix(),
+		"exp":         now.Add(time.Hour).Unix(),
+		"permissions": permissions,
+	}
+}
+
+// claimsRouter answers with the permissions GetClaims finds, behind
+// EnsureValidToken when verifier is set.
+func claimsRouter(verifier TokenVerifier) *gin.Engine {
+	r := gin.New()
+
+	var handlers []gin.HandlerFunc
+	if verifier != nil {
+		handlers = append(handlers, EnsureValidToken(verifier))
+	}
+	handlers = append(handlers, func(c *gin.Context) {
+		claims, customClaims, err := GetClaims(c)
+		if err != nil {
+			AbortWithError(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"sub":         claims.RegisteredClaims.Subject,
+			"permissions": customClaims.Permissions,
+		})
+	})
+
+	r.GET("/claims", handlers...)
+	return r
+}
+
+func getClaims(r http.Handler, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/claims", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestGetClaimsWithValidToken(t *testing.T) {
+	key := newRSAKey(t)
+	server := newJWKSServer(t, key, "key-1")
+
+	verifier, err := NewIssuerVerifier(IssuerConfig{
+		Issuers: []TrustedIssuer{{Issuer: server.URL + "/", Audience: testAudience}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := signToken(t, key, "key-1", tokenClaims(server.URL+"/", "read:book"))
+	w := getClaims(claimsRouter(verifier), token)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+
+	var body struct {
+		Sub         string   `json:"sub"`
+		Permissions []string `json:"permissions"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Sub != "auth0|alice" || len(body.Permissions) != 1 || body.Permissions[0] != "read:book" {
+		t.Errorf("claims = %+v, want auth0|alice with read:book", body)
+	}
+}
+
+func TestGetClaimsRejectsForgedToken(t *testing.T) {
+	key := newRSAKey(t)
+	server := newJWKSServer(t, key, "key-1")
+
+	verifier, err := NewIssuerVerifier(IssuerConfig{
+		Issuers: []TrustedIssuer{{Issuer: server.URL + "/", Audience: testAudience}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"signed with another key", signToken(t, newRSAKey(t), "key-1", tokenClaims(server.URL+"/", "admin"))},
+		{"untrusted issuer", signToken(t, key, "key-1", tokenClaims("https://evil.example.com/", "admin"))},
+		{"not a jwt", "not-a-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := getClaims(claimsRouter(verifier), tt.token)
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, want 401: %s", w.Code, w.Body)
+			}
+			if strings.Contains(w.Body.String(), "admin") {
+				t.Errorf("forged claims reached the handler: %s", w.Body)
+			}
+		})
+	}
+}
+
+func TestGetClaimsWithoutMiddleware(t *testing.T) {
+	key := newRSAKey(t)
+	token := signToken(t, key, "key-1", tokenClaims("https://tenant.example.com/", "admin"))
+
+	w := getClaims(claimsRouter(nil), token)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401: %s", w.Code, w.Body)
+	}
+}
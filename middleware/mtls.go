@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// CertIdentity is what a client certificate is allowed to do.
+type CertIdentity struct {
+	Permissions []string `yaml:"permissions" json:"permissions"`
+	Roles       []string `yaml:"roles" json:"roles"`
+	OrgID       string   `yaml:"org_id" json:"org_id"`
+}
+
+// CertIdentities maps the names a client certificate may carry, its
+// subject common name or any of its DNS, URI or email SANs, to
+// identities. A file of them looks like
+//
+//	identities:
+//	  inventory.internal:
+//	    permissions: ["read:book", "read:author"]
+//	  spiffe://cluster.local/ns/shop/sa/catalog-sync:
+//	    roles: [editor]
+type CertIdentities struct {
+	Identities map[string]CertIdentity `yaml:"identities" json:"identities"`
+}
+
+// LoadCertIdentities reads the identities file at path.
+func LoadCertIdentities(path string) (*CertIdentities, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var identities CertIdentities
+	if err := yaml.Unmarshal(data, &identities); err != nil {
+		return nil, fmt.Errorf("could not decode %s: %w", path, err)
+	}
+
+	return &identities, nil
+}
+
+// Lookup returns the first name of cert with an identity, subject common
+// name first, then the SANs.
+func (m *CertIdentities) Lookup(cert *x509.Certificate) (string, CertIdentity, bool) {
+	names := []string{cert.Subject.CommonName}
+	names = append(names, cert.DNSNames...)
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	names = append(names, cert.EmailAddresses...)
+
+	for _, name := range names {
+		if identity, ok := m.Identities[name]; name != "" && ok {
+			return name, identity, true
+		}
+	}
+
+	return "", CertIdentity{}, false
+}
+
+// NewClientCATLSConfig returns the TLS configuration of a server that
+// verifies client certificates against the CA certificates in the PEM
+// file at path. Clients may still connect without a certificate and
+// authenticate with a token instead.
+func NewClientCATLSConfig(path string) (*tls.Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no CA certificate found in %s", path)
+	}
+
+	return &tls.Config{
+		ClientCAs:  pool,
+		ClientAuth: tls.VerifyClientCertIfGiven,
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
+
+// ClientCertAuth is a middleware that authenticates requests made with a
+// client certificate the TLS handshake verified. The certificate's
+// identity becomes the request's claims, so RequirePermission treats it
+// like a token. A verified certificate without an identity is rejected,
+// requests without one are left to the other authentication middleware.
+// A nil identities turns it off. Rejected certificates are recorded by
+// auditor.
+func ClientCertAuth(identities *CertIdentities, auditor *Auditor) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if identities == nil || c.Request.TLS == nil || len(c.Request.TLS.VerifiedChains) == 0 {
+			c.Next()
+			return
+		}
+
+		cert := c.Request.TLS.VerifiedChains[0][0]
+		name, identity, ok := identities.Lookup(cert)
+		if !ok {
+			auditor.rejected(c, "Client certificate is not authorized: "+cert.Subject.String())
+			AbortWithError(c, http.StatusUnauthorized, "Client certificate is not authorized.")
+			return
+		}
+
+		SetClaims(c, "cert|"+name, CustomClaims{
+			Permissions: identity.Permissions,
+			Roles:       identity.Roles,
+			OrgID:       identity.OrgID,
+		})
+
+		c.Next()
+	}
+}
@@ -0,0 +1,227 @@
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testCA issues client certificates in memory.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCA{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// issue returns a client certificate for template's names.
+func (ca *testCA) issue(t *testing.T, template *x509.Certificate) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestCertIdentitiesLookup(t *testing.T) {
+	ca := newTestCA(t)
+	spiffe, _ := url.Parse("spiffe://cluster.local/ns/shop/sa/catalog-sync")
+
+	identities := &CertIdentities{Identities: map[string]CertIdentity{
+		"inventory.internal":     {Permissions: []string{"read:book"}},
+		"sync.internal":          {Roles: []string{"editor"}},
+		spiffe.String():          {Roles: []string{"reader"}},
+		"robot@shop.example.com": {OrgID: "o1"},
+	}}
+
+	tests := []struct {
+		name     string
+		template *x509.Certificate
+		want     string
+		ok       bool
+	}{
+		{"common name", &x509.Certificate{Subject: pkix.Name{CommonName: "inventory.internal"}}, "inventory.internal", true},
+		{"common name first", &x509.Certificate{Subject: pkix.Name{CommonName: "inventory.internal"}, DNSNames: []string{"sync.internal"}}, "inventory.internal", true},
+		{"dns san", &x509.Certificate{Subject: pkix.Name{CommonName: "unknown"}, DNSNames: []string{"other.internal", "sync.internal"}}, "sync.internal", true},
+		{"uri san", &x509.Certificate{URIs: []*url.URL{spiffe}}, spiffe.String(), true},
+		{"email san", &x509.Certificate{EmailAddresses: []string{"robot@shop.example.com"}}, "robot@shop.example.com", true},
+		{"no identity", &x509.Certificate{Subject: pkix.Name{CommonName: "stranger.internal"}}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert := ca.issue(t, tt.template)
+
+			name, _, ok := identities.Lookup(cert.Leaf)
+			if name != tt.want || ok != tt.ok {
+				t.Errorf("Lookup = %q, %v, want %q, %v", name, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestClientCertAuth(t *testing.T) {
+	ca := newTestCA(t)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, ca.pem, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tlsConfig, err := NewClientCATLSConfig(caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identities := &CertIdentities{Identities: map[string]CertIdentity{
+		"inventory.internal": {Permissions: []string{"read:book"}, OrgID: "o1"},
+	}}
+
+	r := gin.New()
+	r.GET("/claims", ClientCertAuth(identities, nil), func(c *gin.Context) {
+		claims, customClaims, err := GetClaims(c)
+		if err != nil {
+			AbortWithError(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"sub":         claims.RegisteredClaims.Subject,
+			"permissions": customClaims.Permissions,
+			"orgId":       customClaims.OrgID,
+		})
+	})
+
+	server := httptest.NewUnstartedServer(r)
+	server.TLS = tlsConfig
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	// get connects afresh, a reused connection would keep the
+	// certificate of its handshake.
+	get := func(t *testing.T, certs ...tls.Certificate) (*http.Response, error) {
+		transport := server.Client().Transport.(*http.Transport).Clone()
+		transport.TLSClientConfig.Certificates = certs
+		t.Cleanup(transport.CloseIdleConnections)
+
+		client := &http.Client{Transport: transport}
+		return client.Get(server.URL + "/claims")
+	}
+
+	t.Run("known certificate", func(t *testing.T) {
+		res, err := get(t, ca.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "inventory.internal"}}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+
+		var body struct {
+			Sub         string   `json:"sub"`
+			Permissions []string `json:"permissions"`
+			OrgID       string   `json:"orgId"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != http.StatusOK || body.Sub != "cert|inventory.internal" || body.OrgID != "o1" || len(body.Permissions) != 1 {
+			t.Errorf("status %d, claims %+v", res.StatusCode, body)
+		}
+	})
+
+	t.Run("certificate without identity", func(t *testing.T) {
+		res, err := get(t, ca.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "stranger.internal"}}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+
+		if res.StatusCode != http.StatusUnauthorized {
+			t.Errorf("status = %d, want 401", res.StatusCode)
+		}
+	})
+
+	t.Run("certificate of another CA", func(t *testing.T) {
+		other := newTestCA(t)
+		res, err := get(t, other.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "inventory.internal"}}))
+		if err == nil {
+			res.Body.Close()
+			t.Errorf("status = %d, want the handshake to fail", res.StatusCode)
+		}
+	})
+
+	t.Run("no certificate", func(t *testing.T) {
+		res, err := get(t)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+
+		// Left to the token middleware, which isn't mounted here.
+		if res.StatusCode != http.StatusUnauthorized {
+			t.Errorf("status = %d, want 401", res.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/fahmiyonda007/go-gin-gorm/models"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminPermission lets its holder update and delete records they don't own.
+const AdminPermission = "admin"
+
+// Subject returns the sub claim of the validated token, or an empty string.
+func Subject(c *gin.Context) string {
+	claims, _, err := GetClaims(c)
+	if err != nil {
+		return ""
+	}
+	return claims.RegisteredClaims.Subject
+}
+
+// OrgID returns the org_id claim of the validated token, or an empty string.
+func OrgID(c *gin.Context) string {
+	_, customClaims, err := GetClaims(c)
+	if err != nil {
+		return ""
+	}
+	return customClaims.OrgID
+}
+
+// TenantContext returns the request context bound to the caller's
+// organization, see models.WithTenant.
+func TenantContext(c *gin.Context) context.Context {
+	return models.WithTenant(c.Request.Context(), OrgID(c))
+}
+
+// CanModify reports whether the caller may update or delete a record
+// created by ownerSub. It is always true outside ownership mode. It
+// records nothing, so it is safe to call inside a transaction; report a
+// denial with AuditNotOwner once the transaction has ended.
+func (a *Authorizer) CanModify(c *gin.Context, ownerSub string) bool {
+	if !a.ownership {
+		return true
+	}
+
+	claims, customClaims, err := GetClaims(c)
+	if err != nil {
+		return false
+	}
+
+	if ownerSub != "" && claims.RegisteredClaims.Subject == ownerSub {
+		return true
+	}
+
+	return a.allowed(customClaims, AdminPermission)
+}
+
+// AuditNotOwner records that CanModify refused the caller.
+func (a *Authorizer) AuditNotOwner(c *gin.Context) {
+	a.auditor.denied(c, AdminPermission, "Not the owner")
+}
@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/fahmiyonda007/go-gin-gorm/policy"
+	"github.com/gin-gonic/gin"
+)
+
+// Authorizer decides what the caller of a request may do, and audits
+// what it refuses.
+type Authorizer struct {
+	policy    *policy.Engine
+	ownership bool
+	auditor   *Auditor
+}
+
+// AuthorizerConfig is what an Authorizer depends on.
+type AuthorizerConfig struct {
+	// Policy resolves roles and wildcard permissions. Without one only
+	// wildcards in the token's own permissions are expanded.
+	Policy *policy.Engine
+	// Ownership turns ownership mode on: only the creator of a book or
+	// author, or an admin, may update or delete it.
+	Ownership bool
+	// Auditor records the refusals.
+	Auditor *Auditor
+}
+
+// NewAuthorizer returns an Authorizer with the dependencies of config.
+func NewAuthorizer(config AuthorizerConfig) *Authorizer {
+	return &Authorizer{
+		policy:    config.Policy,
+		ownership: config.Ownership,
+		auditor:   config.Auditor,
+	}
+}
+
+// UserAdminPermission lets its holder manage every user of the Auth0
+// tenant, whatever their organization. Unlike AdminPermission it isn't
+// scoped to the caller's organization, so keep it to operators of the
+// whole service.
+const UserAdminPermission = "manage:users"
+
+// Permissions lists every permission the routes check.
+var Permissions = []string{
+	"read:book", "create:book", "update:book", "delete:book",
+	"read:author", "create:author", "update:author", "delete:author",
+	AdminPermission, UserAdminPermission,
+}
+
+func (a *Authorizer) allowed(claims *CustomClaims, permission string) bool {
+	return a.policy.Allowed(claims.Roles, claims.Permissions, permission)
+}
+
+// HasPermission reports whether the caller of c is granted permission,
+// directly or through their roles in the policy.
+func (a *Authorizer) HasPermission(c *gin.Context, permission string) bool {
+	_, claims, err := GetClaims(c)
+	return err == nil && a.allowed(claims, permission)
+}
+
+// EffectivePermissions lists what claims grants once roles and wildcards
+// are expanded: every entry of Permissions it satisfies, followed by any
+// other literal permission the token carries.
+func (a *Authorizer) EffectivePermissions(claims *CustomClaims) []string {
+	effective := []string{}
+	seen := map[string]bool{}
+
+	for _, permission := range Permissions {
+		if a.allowed(claims, permission) {
+			effective = append(effective, permission)
+			seen[permission] = true
+		}
+	}
+
+	for _, permission := range claims.Permissions {
+		if !seen[permission] && !strings.Contains(permission, "*") {
+			effective = append(effective, permission)
+			seen[permission] = true
+		}
+	}
+
+	return effective
+}
+
+// RequirePermission is a middleware that only lets the request through
+// when the validated token grants every one of the given permissions,
+// directly or through its roles in the policy.
+func (a *Authorizer) RequirePermission(permissions ...string) gin.HandlerFunc {
+	return a.requireClaims(func(claims *CustomClaims) string {
+		for _, permission := range permissions {
+			if !a.allowed(claims, permission) {
+				return permission
+			}
+		}
+		return ""
+	})
+}
+
+// RequireAnyPermission is a middleware that only lets the request through
+// when the validated token grants at least one of the given permissions.
+func (a *Authorizer) RequireAnyPermission(permissions ...string) gin.HandlerFunc {
+	return a.requireClaims(func(claims *CustomClaims) string {
+		for _, permission := range permissions {
+			if a.allowed(claims, permission) {
+				return ""
+			}
+		}
+		return strings.Join(permissions, "|")
+	})
+}
+
+// RequireScope is a middleware that only lets the request through
+// when the validated token carries every one of the given scopes.
+func (a *Authorizer) RequireScope(scopes ...string) gin.HandlerFunc {
+	return a.requireClaims(func(claims *CustomClaims) string {
+		for _, scope := range scopes {
+			if !claims.HasScope(scope) {
+				return "scope:" + scope
+			}
+		}
+		return ""
+	})
+}
+
+// RequireAnyScope is a middleware that only lets the request through
+// when the validated token carries at least one of the given scopes.
+func (a *Authorizer) RequireAnyScope(scopes ...string) gin.HandlerFunc {
+	return a.requireClaims(func(claims *CustomClaims) string {
+		for _, scope := range scopes {
+			if claims.HasScope(scope) {
+				return ""
+			}
+		}
+		return "scope:" + strings.Join(scopes, "|")
+	})
+}
+
+// requireClaims lets the request through when missing finds nothing
+// missing from the claims. Otherwise the denial is audited along with
+// what was missing.
+func (a *Authorizer) requireClaims(missing func(claims *CustomClaims) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, claims, err := GetClaims(c)
+		if err != nil {
+			AbortWithError(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		if required := missing(claims); required != "" {
+			a.auditor.denied(c, required, "Insufficient permissions")
+			AbortWithError(c, http.StatusForbidden, "Insufficient permissions")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// AbortWithError stops the chain and writes the error body shared by
+// every authentication and authorization failure.
+func AbortWithError(c *gin.Context, code int, message string) {
+	c.AbortWithStatusJSON(code, gin.H{
+		"code":  code,
+		"error": message,
+	})
+}
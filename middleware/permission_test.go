@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fahmiyonda007/go-gin-gorm/policy"
+)
+
+func TestEffectivePermissions(t *testing.T) {
+	engine, err := policy.New(policy.File{Roles: map[string]policy.Role{
+		"reader": {Permissions: []string{"read:*"}},
+		"editor": {Inherits: []string{"reader"}, Permissions: []string{"create:*", "update:*"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorizer := NewAuthorizer(AuthorizerConfig{Policy: engine})
+
+	tests := []struct {
+		name   string
+		claims CustomClaims
+		want   []string
+	}{
+		{"no grants", CustomClaims{}, []string{}},
+		{"editor role", CustomClaims{Roles: []string{"editor"}}, []string{
+			"read:book", "create:book", "update:book",
+			"read:author", "create:author", "update:author",
+		}},
+		{"wildcard permission", CustomClaims{Permissions: []string{"*:book"}}, []string{
+			"read:book", "create:book", "update:book", "delete:book",
+		}},
+		{"literal permissions", CustomClaims{Permissions: []string{"delete:author", "export:report"}}, []string{
+			"delete:author", "export:report",
+		}},
+		{"everything", CustomClaims{Permissions: []string{"*"}}, Permissions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := authorizer.EffectivePermissions(&tt.claims)
+			if got == nil || strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("EffectivePermissions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package middleware
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"os"
+
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// SessionCookieName holds the encrypted access token in session mode.
+	SessionCookieName = "session"
+	// CSRFCookieName holds the double-submit CSRF token. Unlike the session
+	// cookie it is readable from JavaScript.
+	CSRFCookieName = "csrf_token"
+	// CSRFHeaderName must echo the CSRF cookie on unsafe requests that
+	// authenticate with the session cookie.
+	CSRFHeaderName = "X-CSRF-Token"
+	// RefreshCookieName holds the encrypted refresh token in session mode.
+	RefreshCookieName = "refresh_token"
+)
+
+// refreshCookieMaxAge keeps the refresh cookie for 30 days. Auth0 still
+// enforces the lifetime of the refresh token itself.
+const refreshCookieMaxAge = 30 * 24 * 60 * 60
+
+// SessionCodec encrypts access tokens into session cookies for browser
+// clients, so the token itself is never exposed to JavaScript.
+type SessionCodec struct {
+	aead cipher.AEAD
+}
+
+// NewSessionCodec derives an AES-GCM key from secret.
+func NewSessionCodec(secret string) (*SessionCodec, error) {
+	if secret == "" {
+		return nil, errors.New("session secret is empty")
+	}
+
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SessionCodec{aead: aead}, nil
+}
+
+// NewSessionCodecFromEnv enables session mode when SESSION_SECRET is set.
+// It returns nil without an error when session mode is off.
+func NewSessionCodecFromEnv() (*SessionCodec, error) {
+	secret := os.Getenv("SESSION_SECRET")
+	if secret == "" {
+		return nil, nil
+	}
+
+	return NewSessionCodec(secret)
+}
+
+// Seal encrypts token into a cookie value.
+func (s *SessionCodec) Seal(token string) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := s.aead.Seal(nonce, nonce, []byte(token), nil)
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a cookie value produced by Seal.
+func (s *SessionCodec) Open(value string) (string, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < s.aead.NonceSize() {
+		return "", errors.New("session cookie is too short")
+	}
+
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	token, err := s.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(token), nil
+}
+
+// SetCookies stores token in the session cookie together with a fresh
+// CSRF cookie. Both expire after maxAge seconds.
+func (s *SessionCodec) SetCookies(w http.ResponseWriter, token string, maxAge int) error {
+	value, err := s.Seal(token)
+	if err != nil {
+		return err
+	}
+
+	csrf := make([]byte, 32)
+	if _, err := rand.Read(csrf); err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     CSRFCookieName,
+		Value:    base64.RawURLEncoding.EncodeToString(csrf),
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return nil
+}
+
+// SetRefreshCookie stores the refresh token in its own cookie, so the
+// browser can renew the session without JavaScript ever reading it.
+func (s *SessionCodec) SetRefreshCookie(w http.ResponseWriter, token string) error {
+	value, err := s.Seal(token)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     RefreshCookieName,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   refreshCookieMaxAge,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return nil
+}
+
+// RefreshToken reads the refresh token stored by SetRefreshCookie.
+func (s *SessionCodec) RefreshToken(r *http.Request) (string, error) {
+	cookie, err := r.Cookie(RefreshCookieName)
+	if err != nil {
+		return "", err
+	}
+
+	return s.Open(cookie.Value)
+}
+
+// ClearCookies removes the session, refresh and CSRF cookies.
+func (s *SessionCodec) ClearCookies(w http.ResponseWriter) {
+	for _, name := range []string{SessionCookieName, RefreshCookieName, CSRFCookieName} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			Path:     "/",
+			MaxAge:   -1,
+			Secure:   true,
+			HttpOnly: name != CSRFCookieName,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+}
+
+// tokenExtractor reads the bearer token from the Authorization header and
+// falls back to the session cookie.
+func (s *SessionCodec) tokenExtractor(r *http.Request) (string, error) {
+	token, err := jwtmiddleware.AuthHeaderTokenExtractor(r)
+	if err != nil || token != "" {
+		return token, err
+	}
+
+	cookie, err := r.Cookie(SessionCookieName)
+	if err != nil {
+		return "", nil
+	}
+
+	return s.Open(cookie.Value)
+}
+
+// RawToken reads the token EnsureValidToken validated back from the
+// request, for handlers that call other APIs on the user's behalf.
+// sessions may be nil when session mode is off.
+func RawToken(r *http.Request, sessions *SessionCodec) (string, error) {
+	if sessions != nil {
+		return sessions.tokenExtractor(r)
+	}
+	return jwtmiddleware.AuthHeaderTokenExtractor(r)
+}
+
+// WithSessionCookie lets EnsureValidToken accept the session cookie set by
+// SessionCodec.SetCookies in place of the Authorization header.
+func WithSessionCookie(sessions *SessionCodec) Option {
+	return func(o *options) {
+		if sessions != nil {
+			o.middleware = append(o.middleware, jwtmiddleware.WithTokenExtractor(sessions.tokenExtractor))
+		}
+	}
+}
+
+// RequireCSRF is a middleware that protects unsafe requests authenticated
+// with the session cookie. They must send the CSRF cookie back in the
+// X-CSRF-Token header, which a cross-site form can't do. Requests carrying
+// an Authorization header are not exposed to CSRF and pass through.
+func RequireCSRF() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		if c.GetHeader("Authorization") != "" {
+			c.Next()
+			return
+		}
+
+		if _, err := c.Cookie(SessionCookieName); err != nil {
+			c.Next()
+			return
+		}
+
+		cookie, err := c.Cookie(CSRFCookieName)
+		header := c.GetHeader(CSRFHeaderName)
+		if err != nil || header == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) != 1 {
+			AbortWithError(c, http.StatusForbidden, "CSRF token missing or invalid")
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newSessionCodec(t *testing.T) *SessionCodec {
+	t.Helper()
+
+	sessions, err := NewSessionCodec("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sessions
+}
+
+// sessionCookies returns the cookies SetCookies gives a browser for token.
+func sessionCookies(t *testing.T, sessions *SessionCodec, token string) (*http.Cookie, *http.Cookie) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	if err := sessions.SetCookies(w, token, 3600); err != nil {
+		t.Fatal(err)
+	}
+
+	var session, csrf *http.Cookie
+	for _, cookie := range w.Result().Cookies() {
+		switch cookie.Name {
+		case SessionCookieName:
+			session = cookie
+		case CSRFCookieName:
+			csrf = cookie
+		}
+	}
+	if session == nil || csrf == nil {
+		t.Fatalf("cookies = %v, want a session and a CSRF cookie", w.Result().Cookies())
+	}
+	if !session.HttpOnly || csrf.HttpOnly {
+		t.Errorf("session HttpOnly = %t, CSRF HttpOnly = %t, want only the session hidden from JavaScript", session.HttpOnly, csrf.HttpOnly)
+	}
+	return session, csrf
+}
+
+func TestSessionCodecSealAndOpen(t *testing.T) {
+	sessions := newSessionCodec(t)
+
+	value, err := sessions.Seal("access-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token, err := sessions.Open(value); err != nil || token != "access-token" {
+		t.Errorf("Open() = %q, %v, want access-token", token, err)
+	}
+
+	tampered := []byte(value)
+	tampered[len(tampered)-1] ^= 1
+	other, err := NewSessionCodec("other secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		sessions *SessionCodec
+		value    string
+	}{
+		{"tampered", sessions, string(tampered)},
+		{"another secret", other, value},
+		{"too short", sessions, "AAAA"},
+		{"not base64", sessions, "!!!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if token, err := tt.sessions.Open(tt.value); err == nil {
+				t.Errorf("Open() = %q, want an error", token)
+			}
+		})
+	}
+}
+
+func TestRequireCSRF(t *testing.T) {
+	sessions := newSessionCodec(t)
+	session, csrf := sessionCookies(t, sessions, "access-token")
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router := gin.New()
+	router.Use(RequireCSRF())
+	router.GET("/books", ok)
+	router.POST("/books", ok)
+
+	tests := []struct {
+		name          string
+		method        string
+		cookies       []*http.Cookie
+		csrfHeader    string
+		authorization string
+		want          int
+	}{
+		{"session with matching header", http.MethodPost, []*http.Cookie{session, csrf}, csrf.Value, "", http.StatusOK},
+		{"session without header", http.MethodPost, []*http.Cookie{session, csrf}, "", "", http.StatusForbidden},
+		{"session with mismatched header", http.MethodPost, []*http.Cookie{session, csrf}, "forged", "", http.StatusForbidden},
+		{"session without CSRF cookie", http.MethodPost, []*http.Cookie{session}, csrf.Value, "", http.StatusForbidden},
+		{"authorization header", http.MethodPost, []*http.Cookie{session, csrf}, "", "Bearer access-token", http.StatusOK},
+		{"no session", http.MethodPost, nil, "", "", http.StatusOK},
+		{"safe method", http.MethodGet, []*http.Cookie{session, csrf}, "", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/books", nil)
+			for _, cookie := range tt.cookies {
+				req.AddCookie(cookie)
+			}
+			if tt.csrfHeader != "" {
+				req.Header.Set(CSRFHeaderName, tt.csrfHeader)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestTokenExtractor(t *testing.T) {
+	sessions := newSessionCodec(t)
+	session, _ := sessionCookies(t, sessions, "cookie-token")
+
+	tests := []struct {
+		name          string
+		authorization string
+		cookie        *http.Cookie
+		want          string
+		wantErr       bool
+	}{
+		{"header wins over the cookie", "Bearer header-token", session, "header-token", false},
+		{"falls back to the cookie", "", session, "cookie-token", false},
+		{"neither", "", nil, "", false},
+		{"tampered cookie", "", &http.Cookie{Name: SessionCookieName, Value: session.Value[:len(session.Value)-2]}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			if tt.cookie != nil {
+				req.AddCookie(tt.cookie)
+			}
+
+			token, err := sessions.tokenExtractor(req)
+			if (err != nil) != tt.wantErr || token != tt.want {
+				t.Errorf("tokenExtractor() = %q, %v, want %q", token, err, tt.want)
+			}
+			if raw, _ := RawToken(req, sessions); !tt.wantErr && raw != tt.want {
+				t.Errorf("RawToken() = %q, want %q", raw, tt.want)
+			}
+		})
+	}
+}
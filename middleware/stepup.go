@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/gin-gonic/gin"
+)
+
+// InsufficientUserAuthentication is the error of RFC 9470 telling the
+// client to send the user through login again before retrying.
+const InsufficientUserAuthentication = "insufficient_user_authentication"
+
+// StepUpOutput is the body of a step-up challenge.
+type StepUpOutput struct {
+	Code    int      `json:"code"`
+	Error   string   `json:"error"`
+	Message string   `json:"message"`
+	MaxAge  int      `json:"max_age,omitempty"`
+	AMR     []string `json:"amr,omitempty"`
+}
+
+// RequireStepUp is a middleware for destructive routes. It only lets the
+// request through when the user authenticated within maxAge, or with one
+// of the amr methods such as "mfa". Otherwise it answers 401 with the
+// insufficient_user_authentication error, in the body and in the
+// WWW-Authenticate header, so the client can log the user in again. A
+// zero maxAge and no amr turn it off.
+//
+// API keys, client certificates and client credentials tokens have no
+// user to log in again, so they pass on their permissions alone.
+func (a *Authorizer) RequireStepUp(maxAge time.Duration, amr []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxAge <= 0 && len(amr) == 0 {
+			c.Next()
+			return
+		}
+
+		validated, claims, err := GetClaims(c)
+		if err != nil {
+			AbortWithError(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		if nonInteractive(validated, claims) {
+			c.Next()
+			return
+		}
+
+		for _, method := range amr {
+			if contains(claims.AMR, method) {
+				c.Next()
+				return
+			}
+		}
+
+		if maxAge > 0 && claims.AuthTime > 0 && time.Since(time.Unix(claims.AuthTime, 0)) <= maxAge {
+			c.Next()
+			return
+		}
+
+		output := StepUpOutput{
+			Code:    http.StatusUnauthorized,
+			Error:   InsufficientUserAuthentication,
+			Message: "A more recent or stronger authentication is required.",
+			MaxAge:  int(maxAge.Seconds()),
+			AMR:     amr,
+		}
+
+		challenge := fmt.Sprintf("Bearer error=%q, error_description=%q", output.Error, output.Message)
+		if output.MaxAge > 0 {
+			challenge += ", max_age=" + strconv.Itoa(output.MaxAge)
+		}
+		c.Header("WWW-Authenticate", challenge)
+
+		a.auditor.denied(c, "step_up", output.Message)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, output)
+	}
+}
+
+// nonInteractive reports whether the caller is a machine rather than a
+// user who could authenticate again. API keys and certificates are told
+// apart from tokens by their missing issuer, so a token whose subject
+// merely looks like theirs is not exempt.
+func nonInteractive(validated *validator.ValidatedClaims, claims *CustomClaims) bool {
+	if claims.Gty == "client-credentials" {
+		return true
+	}
+
+	subject := validated.RegisteredClaims.Subject
+	return validated.RegisteredClaims.Issuer == "" &&
+		(strings.HasPrefix(subject, "apikey|") || strings.HasPrefix(subject, "cert|"))
+}
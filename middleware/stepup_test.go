@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireStepUp(t *testing.T) {
+	// setToken stands in for EnsureValidToken, which always leaves an
+	// issuer in the claims.
+	setToken := func(subject string, claims CustomClaims) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			validated := &validator.ValidatedClaims{
+				RegisteredClaims: validator.RegisteredClaims{Issuer: "https://tenant.example.com/", Subject: subject},
+				CustomClaims:     &claims,
+			}
+			c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), jwtmiddleware.ContextKey{}, validated))
+		}
+	}
+	setClaims := func(subject string) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			SetClaims(c, subject, CustomClaims{Permissions: []string{"delete:author"}})
+		}
+	}
+
+	tests := []struct {
+		name        string
+		credentials gin.HandlerFunc
+		want        int
+	}{
+		{"recent login", setToken("auth0|alice", CustomClaims{AuthTime: time.Now().Add(-time.Minute).Unix()}), http.StatusOK},
+		{"mfa", setToken("auth0|alice", CustomClaims{AMR: []string{"pwd", "mfa"}}), http.StatusOK},
+		{"old login", setToken("auth0|alice", CustomClaims{AuthTime: time.Now().Add(-time.Hour).Unix()}), http.StatusUnauthorized},
+		{"no auth_time", setToken("auth0|alice", CustomClaims{}), http.StatusUnauthorized},
+		{"client credentials token", setToken("client-id@clients", CustomClaims{Gty: "client-credentials"}), http.StatusOK},
+		{"api key", setClaims("apikey|1"), http.StatusOK},
+		{"client certificate", setClaims("cert|inventory.internal"), http.StatusOK},
+		{"token posing as an api key", setToken("apikey|1", CustomClaims{}), http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.DELETE("/", tt.credentials, NewAuthorizer(AuthorizerConfig{}).RequireStepUp(5*time.Minute, []string{"mfa"}), func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"data": true})
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/", nil))
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if tt.want == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("challenge lacks the WWW-Authenticate header")
+			}
+		})
+	}
+}
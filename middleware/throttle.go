@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// ThrottlePolicy decides when repeated login failures lock a key out.
+type ThrottlePolicy struct {
+	// MaxAttempts is the number of failures allowed before the first lockout.
+	MaxAttempts int
+	// Window is how long a failure is remembered.
+	Window time.Duration
+	// BaseLockout is the first lockout, doubled for every further failure.
+	BaseLockout time.Duration
+	// MaxLockout caps the lockout.
+	MaxLockout time.Duration
+}
+
+// DefaultUserThrottle locks a username out after five failures.
+var DefaultUserThrottle = ThrottlePolicy{
+	MaxAttempts: 5,
+	Window:      15 * time.Minute,
+	BaseLockout: 30 * time.Second,
+	MaxLockout:  15 * time.Minute,
+}
+
+// DefaultIPThrottle is looser than DefaultUserThrottle since many users
+// can share an address behind a NAT.
+var DefaultIPThrottle = ThrottlePolicy{
+	MaxAttempts: 20,
+	Window:      15 * time.Minute,
+	BaseLockout: 30 * time.Second,
+	MaxLockout:  time.Hour,
+}
+
+// lockout returns how long the key is locked out after its n-th failure.
+func (p ThrottlePolicy) lockout(failures int) time.Duration {
+	if failures < p.MaxAttempts {
+		return 0
+	}
+
+	factor := math.Pow(2, float64(failures-p.MaxAttempts))
+	d := time.Duration(float64(p.BaseLockout) * factor)
+	if d <= 0 || d > p.MaxLockout {
+		return p.MaxLockout
+	}
+	return d
+}
+
+// NewAttemptStoreFromEnv shares login attempts through Redis when
+// REDIS_URL is set and keeps them in memory otherwise.
+func NewAttemptStoreFromEnv() (AttemptStore, error) {
+	redisURL := os.Getenv("REDIS_URL")
+	if redisURL == "" {
+		return NewMemoryAttemptStore(), nil
+	}
+
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRedisAttemptStore(redis.NewClient(opts), "login:"), nil
+}
+
+// LoginThrottle is a middleware for the login route that counts failed
+// attempts per username and per client IP. Once a policy's MaxAttempts is
+// reached further attempts are refused with 429 and a Retry-After header
+// for an exponentially growing lockout. A successful login resets the
+// username. Errors from store are logged and let the request through.
+func LoginThrottle(store AttemptStore, perUser ThrottlePolicy, perIP ThrottlePolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		userKey := "user:" + strings.ToLower(peekUsername(c))
+		ipKey := "ip:" + c.ClientIP()
+
+		var retryAfter time.Duration
+		for _, key := range []string{userKey, ipKey} {
+			locked, err := store.LockedFor(ctx, key)
+			if err != nil {
+				log.Printf("Error reading login attempts: %v", err)
+				continue
+			}
+			if locked > retryAfter {
+				retryAfter = locked
+			}
+		}
+
+		if retryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			AbortWithError(c, http.StatusTooManyRequests, "Too many failed login attempts, try again later")
+			return
+		}
+
+		c.Next()
+
+		switch c.Writer.Status() {
+		case http.StatusOK:
+			if err := store.Reset(ctx, userKey); err != nil {
+				log.Printf("Error resetting login attempts: %v", err)
+			}
+		case http.StatusUnauthorized:
+			fail(c, store, userKey, perUser)
+			fail(c, store, ipKey, perIP)
+		}
+	}
+}
+
+func fail(c *gin.Context, store AttemptStore, key string, policy ThrottlePolicy) {
+	ctx := c.Request.Context()
+
+	failures, err := store.Fail(ctx, key, policy.Window)
+	if err != nil {
+		log.Printf("Error recording a failed login attempt: %v", err)
+		return
+	}
+
+	if d := policy.lockout(failures); d > 0 {
+		if err := store.Lock(ctx, key, d); err != nil {
+			log.Printf("Error locking out %s: %v", key, err)
+		}
+	}
+}
+
+// maxLoginBodySize bounds the login body peekUsername reads before the
+// caller is authenticated.
+const maxLoginBodySize = 64 << 10
+
+// peekUsername reads the username from a JSON login body and puts the body
+// back for the handler. Bodies over maxLoginBodySize are cut short, which
+// the handler sees as invalid JSON.
+func peekUsername(c *gin.Context) string {
+	r := c.Request
+	if r.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, r.Body, maxLoginBodySize))
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+
+	var input struct {
+		Username string `json:"username"`
+	}
+	_ = json.Unmarshal(body, &input)
+
+	return input.Username
+}
@@ -0,0 +1,183 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// AttemptStore counts failed login attempts and remembers lockouts. Keys
+// are opaque to the store; LoginThrottle namespaces them per username and
+// per client IP.
+type AttemptStore interface {
+	// Fail records a failed attempt for key and returns the number of
+	// failures seen since the counter was last reset. The counter is
+	// forgotten once no failure happened for window.
+	Fail(ctx context.Context, key string, window time.Duration) (int, error)
+	// Lock locks key out for d.
+	Lock(ctx context.Context, key string, d time.Duration) error
+	// LockedFor returns how long key stays locked out, or zero.
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+	// Reset forgets the failures and the lockout of key.
+	Reset(ctx context.Context, key string) error
+}
+
+// sweepInterval is how often MemoryAttemptStore drops expired entries, so
+// failures for random usernames don't pile up forever.
+const sweepInterval = time.Minute
+
+// MemoryAttemptStore is an AttemptStore for a single instance of the API.
+type MemoryAttemptStore struct {
+	mu        sync.Mutex
+	failures  map[string]attemptCounter
+	locks     map[string]time.Time
+	nextSweep time.Time
+}
+
+type attemptCounter struct {
+	count     int
+	expiresAt time.Time
+}
+
+// NewMemoryAttemptStore creates an empty MemoryAttemptStore.
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{
+		failures: map[string]attemptCounter{},
+		locks:    map[string]time.Time{},
+	}
+}
+
+// Fail satisfies AttemptStore.
+func (s *MemoryAttemptStore) Fail(ctx context.Context, key string, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	counter := s.failures[key]
+	if now.After(counter.expiresAt) {
+		counter.count = 0
+	}
+
+	counter.count++
+	counter.expiresAt = now.Add(window)
+	s.failures[key] = counter
+
+	return counter.count, nil
+}
+
+// Lock satisfies AttemptStore.
+func (s *MemoryAttemptStore) Lock(ctx context.Context, key string, d time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	s.locks[key] = now.Add(d)
+	return nil
+}
+
+// LockedFor satisfies AttemptStore.
+func (s *MemoryAttemptStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	until, ok := s.locks[key]
+	if !ok {
+		return 0, nil
+	}
+
+	remaining := time.Until(until)
+	if remaining <= 0 {
+		delete(s.locks, key)
+		return 0, nil
+	}
+
+	return remaining, nil
+}
+
+// Reset satisfies AttemptStore.
+func (s *MemoryAttemptStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.failures, key)
+	delete(s.locks, key)
+	return nil
+}
+
+// sweep drops expired failures and lockouts, at most once per
+// sweepInterval. The caller holds s.mu.
+func (s *MemoryAttemptStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	s.nextSweep = now.Add(sweepInterval)
+
+	for key, counter := range s.failures {
+		if now.After(counter.expiresAt) {
+			delete(s.failures, key)
+		}
+	}
+	for key, until := range s.locks {
+		if now.After(until) {
+			delete(s.locks, key)
+		}
+	}
+}
+
+// RedisAttemptStore is an AttemptStore shared by every instance of the API
+// through Redis or any server speaking its protocol.
+type RedisAttemptStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisAttemptStore stores its keys under prefix in client.
+func NewRedisAttemptStore(client redis.UniversalClient, prefix string) *RedisAttemptStore {
+	return &RedisAttemptStore{client: client, prefix: prefix}
+}
+
+// Fail satisfies AttemptStore.
+func (s *RedisAttemptStore) Fail(ctx context.Context, key string, window time.Duration) (int, error) {
+	failuresKey := s.prefix + "failures:" + key
+
+	pipe := s.client.TxPipeline()
+	incr := pipe.Incr(ctx, failuresKey)
+	pipe.PExpire(ctx, failuresKey, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	return int(incr.Val()), nil
+}
+
+// Lock satisfies AttemptStore.
+func (s *RedisAttemptStore) Lock(ctx context.Context, key string, d time.Duration) error {
+	return s.client.Set(ctx, s.prefix+"lock:"+key, 1, d).Err()
+}
+
+// LockedFor satisfies AttemptStore.
+func (s *RedisAttemptStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := s.client.PTTL(ctx, s.prefix+"lock:"+key).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	// PTTL answers negative durations for missing keys and keys without
+	// an expiry, neither of which is a lockout.
+	if ttl < 0 {
+		return 0, nil
+	}
+
+	return ttl, nil
+}
+
+// Reset satisfies AttemptStore.
+func (s *RedisAttemptStore) Reset(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.prefix+"failures:"+key, s.prefix+"lock:"+key).Err()
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newRedisAttemptStore(t *testing.T) (*RedisAttemptStore, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return NewRedisAttemptStore(client, "login:"), server
+}
+
+// testAttemptStore checks the behaviour every AttemptStore shares.
+func testAttemptStore(t *testing.T, store AttemptStore) {
+	ctx := context.Background()
+
+	for want := 1; want <= 3; want++ {
+		got, err := store.Fail(ctx, "user:alice", time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Fail = %d, want %d", got, want)
+		}
+	}
+
+	if got, err := store.Fail(ctx, "user:bob", time.Minute); err != nil || got != 1 {
+		t.Errorf("Fail(bob) = %d, %v, want 1", got, err)
+	}
+
+	if d, err := store.LockedFor(ctx, "user:alice"); err != nil || d != 0 {
+		t.Errorf("LockedFor before Lock = %v, %v, want 0", d, err)
+	}
+
+	if err := store.Lock(ctx, "user:alice", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if d, err := store.LockedFor(ctx, "user:alice"); err != nil || d <= 0 || d > time.Minute {
+		t.Errorf("LockedFor after Lock = %v, %v, want up to a minute", d, err)
+	}
+
+	if err := store.Reset(ctx, "user:alice"); err != nil {
+		t.Fatal(err)
+	}
+	if d, err := store.LockedFor(ctx, "user:alice"); err != nil || d != 0 {
+		t.Errorf("LockedFor after Reset = %v, %v, want 0", d, err)
+	}
+	if got, err := store.Fail(ctx, "user:alice", time.Minute); err != nil || got != 1 {
+		t.Errorf("Fail after Reset = %d, %v, want 1", got, err)
+	}
+}
+
+func TestMemoryAttemptStore(t *testing.T) {
+	testAttemptStore(t, NewMemoryAttemptStore())
+}
+
+func TestRedisAttemptStore(t *testing.T) {
+	store, _ := newRedisAttemptStore(t)
+	testAttemptStore(t, store)
+}
+
+func TestRedisAttemptStoreExpires(t *testing.T) {
+	store, server := newRedisAttemptStore(t)
+	ctx := context.Background()
+
+	if _, err := store.Fail(ctx, "user:alice", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Lock(ctx, "user:alice", 30*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	server.FastForward(time.Minute + time.Second)
+
+	if got, err := store.Fail(ctx, "user:alice", time.Minute); err != nil || got != 1 {
+		t.Errorf("Fail after the window = %d, %v, want 1", got, err)
+	}
+	if d, err := store.LockedFor(ctx, "user:alice"); err != nil || d != 0 {
+		t.Errorf("LockedFor after the lockout = %v, %v, want 0", d, err)
+	}
+	if !server.Exists("login:failures:user:alice") {
+		t.Error("failures are not stored under the prefix")
+	}
+}
+
+func TestMemoryAttemptStorePrunesExpiredEntries(t *testing.T) {
+	store := NewMemoryAttemptStore()
+	ctx := context.Background()
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("user:%d", i)
+		if _, err := store.Fail(ctx, key, time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Lock(ctx, key, time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	// Pretend the last sweep is long past, as it is for a real spray.
+	store.nextSweep = time.Time{}
+	if _, err := store.Fail(ctx, "user:alice", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(store.failures) != 1 || len(store.locks) != 0 {
+		t.Errorf("store keeps %d failures and %d locks, want 1 and 0", len(store.failures), len(store.locks))
+	}
+}
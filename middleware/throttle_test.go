@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// loginRouter serves a login route behind LoginThrottle that refuses
+// every attempt, without trusting any proxy like main does by default.
+func loginRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+
+	r := gin.New()
+	if err := r.SetTrustedProxies(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	perIP := ThrottlePolicy{MaxAttempts: 2, Window: time.Minute, BaseLockout: time.Minute, MaxLockout: time.Minute}
+	r.POST("/login", LoginThrottle(NewMemoryAttemptStore(), DefaultUserThrottle, perIP), func(c *gin.Context) {
+		c.Status(http.StatusUnauthorized)
+	})
+	return r
+}
+
+func TestLoginThrottleIgnoresForwardedFor(t *testing.T) {
+	r := loginRouter(t)
+
+	var codes []int
+	for _, forwarded := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"username":"alice"}`))
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("X-Forwarded-For", forwarded)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		codes = append(codes, w.Code)
+	}
+
+	if codes[2] != http.StatusTooManyRequests {
+		t.Errorf("statuses = %v, want the peer locked out despite X-Forwarded-For", codes)
+	}
+}
+
+func TestPeekUsernameBoundsTheBody(t *testing.T) {
+	var read int
+	r := gin.New()
+	r.POST("/login", func(c *gin.Context) {
+		if username := peekUsername(c); username != "" {
+			t.Errorf("username = %q from an oversized body", username)
+		}
+		body, _ := io.ReadAll(c.Request.Body)
+		read = len(body)
+	})
+
+	body := `{"username":"alice","padding":"` + strings.Repeat("x", 2*maxLoginBodySize) + `"}`
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body)))
+
+	if read > maxLoginBodySize {
+		t.Errorf("handler read %d bytes, want at most %d", read, maxLoginBodySize)
+	}
+}
+
+func TestPeekUsername(t *testing.T) {
+	r := gin.New()
+	r.POST("/login", func(c *gin.Context) {
+		if username := peekUsername(c); username != "alice" {
+			t.Errorf("username = %q, want alice", username)
+		}
+		if body, _ := io.ReadAll(c.Request.Body); !strings.Contains(string(body), "alice") {
+			t.Errorf("handler body = %q, want it put back", body)
+		}
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"username":"alice"}`)))
+}
@@ -0,0 +1,266 @@
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"github.com/golang-jwt/jwt/v4"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// TokenVerifier validates a raw bearer token and returns the claims
+// EnsureValidToken stores in the request context.
+type TokenVerifier interface {
+	ValidateToken(ctx context.Context, token string) (interface{}, error)
+}
+
+// NewTokenVerifier builds the verifier selected by AUTH_MODE. It defaults
+// to Auth0 and switches to a LocalVerifier when AUTH_MODE=local. With
+// Auth0, opaque tokens are introspected when INTROSPECTION_URL is set.
+func NewTokenVerifier() (TokenVerifier, error) {
+	audience := os.Getenv("AUTH0_AUDIENCE")
+
+	switch os.Getenv("AUTH_MODE") {
+	case "", "auth0":
+		config, err := NewIssuerConfigFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		verifier, err := NewIssuerVerifier(config)
+		if err != nil {
+			return nil, err
+		}
+
+		endpoint := os.Getenv("INTROSPECTION_URL")
+		if endpoint == "" {
+			return verifier, nil
+		}
+		return &OpaqueTokenVerifier{
+			JWT: verifier,
+			Opaque: NewIntrospectionVerifier(endpoint,
+				os.Getenv("INTROSPECTION_CLIENTID"),
+				os.Getenv("INTROSPECTION_CLIENTSECRET"),
+				os.Getenv("INTROSPECTION_AUDIENCE"),
+			),
+		}, nil
+	case "local":
+		issuer := os.Getenv("LOCAL_ISSUER")
+		if issuer == "" {
+			issuer = "http://localhost:" + os.Getenv("APP_PORT") + "/"
+		}
+
+		if path := os.Getenv("LOCAL_JWKS_FILE"); path != "" {
+			return NewLocalVerifierFromJWKS(issuer, audience, path)
+		}
+		if path := os.Getenv("LOCAL_KEY_FILE"); path != "" {
+			return NewLocalVerifierFromPEM(issuer, audience, path)
+		}
+
+		log.Println("LOCAL_KEY_FILE is not set, signing tokens with a throwaway key")
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+		return NewLocalVerifier(issuer, audience, key)
+	default:
+		return nil, fmt.Errorf("unknown AUTH_MODE %q", os.Getenv("AUTH_MODE"))
+	}
+}
+
+// NewAuth0Verifier validates RS256 tokens issued by the Auth0 tenant
+// at domain, fetching its signing keys from the tenant's JWKS endpoint.
+func NewAuth0Verifier(domain string, audience string) (TokenVerifier, error) {
+	return NewIssuerVerifier(IssuerConfig{
+		Issuers: []TrustedIssuer{{Issuer: issuerURL(domain), Audience: audience}},
+	})
+}
+
+// LocalVerifier validates tokens signed by a key available on disk or in
+// memory, so the API can run without reaching Auth0. When it holds the
+// private key it can also mint tokens through Mint.
+type LocalVerifier struct {
+	issuer     string
+	audience   string
+	keyID      string
+	privateKey *rsa.PrivateKey
+	validator  *validator.Validator
+}
+
+// NewLocalVerifier creates a LocalVerifier that signs and validates
+// tokens with key.
+func NewLocalVerifier(issuer string, audience string, key *rsa.PrivateKey) (*LocalVerifier, error) {
+	v, err := newLocalVerifier(issuer, audience, &key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	v.privateKey = key
+	return v, nil
+}
+
+// NewLocalVerifierFromPEM creates a LocalVerifier from a PEM file. A
+// private key enables Mint, a public key or certificate only validates.
+func NewLocalVerifierFromPEM(issuer string, audience string, path string) (*LocalVerifier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", path)
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return NewLocalVerifier(issuer, audience, key)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%s does not contain an RSA private key", path)
+		}
+		return NewLocalVerifier(issuer, audience, rsaKey)
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("%s does not contain an RSA public key", path)
+		}
+		return newLocalVerifier(issuer, audience, rsaKey)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("%s does not contain an RSA certificate", path)
+		}
+		return newLocalVerifier(issuer, audience, rsaKey)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q in %s", block.Type, path)
+	}
+}
+
+// NewLocalVerifierFromJWKS creates a LocalVerifier that validates tokens
+// against the keys of a JWKS file, such as a saved copy of
+// https://AUTH0_DOMAIN/.well-known/jwks.json.
+func NewLocalVerifierFromJWKS(issuer string, audience string, path string) (*LocalVerifier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keySet jose.JSONWebKeySet
+	if err := json.Unmarshal(data, &keySet); err != nil {
+		return nil, fmt.Errorf("could not decode jwks %s: %w", path, err)
+	}
+
+	jwtValidator, err := validator.New(func(ctx context.Context) (interface{}, error) {
+		return &keySet, nil
+	},
+		validator.RS256,
+		issuer,
+		[]string{audience},
+		validator.WithCustomClaims(newCustomClaims),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalVerifier{
+		issuer:    issuer,
+		audience:  audience,
+		validator: jwtValidator,
+	}, nil
+}
+
+func newLocalVerifier(issuer string, audience string, key *rsa.PublicKey) (*LocalVerifier, error) {
+	jwtValidator, err := validator.New(func(ctx context.Context) (interface{}, error) {
+		return key, nil
+	},
+		validator.RS256,
+		issuer,
+		[]string{audience},
+		validator.WithCustomClaims(newCustomClaims),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	thumbprint, err := (&jose.JSONWebKey{Key: key}).Thumbprint(crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalVerifier{
+		issuer:    issuer,
+		audience:  audience,
+		keyID:     base64.RawURLEncoding.EncodeToString(thumbprint),
+		validator: jwtValidator,
+	}, nil
+}
+
+// ValidateToken satisfies TokenVerifier.
+func (v *LocalVerifier) ValidateToken(ctx context.Context, token string) (interface{}, error) {
+	return v.validator.ValidateToken(ctx, token)
+}
+
+// CanSign reports whether Mint is available.
+func (v *LocalVerifier) CanSign() bool {
+	return v.privateKey != nil
+}
+
+// Mint signs a token for subject carrying claims that expires after ttl.
+func (v *LocalVerifier) Mint(subject string, claims CustomClaims, ttl time.Duration) (string, error) {
+	if !v.CanSign() {
+		return "", errors.New("local verifier has no private key to sign with")
+	}
+
+	// Round-trip through JSON so every CustomClaims field lands in the token
+	// under the same name the validator reads it back from.
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	mapClaims := jwt.MapClaims{}
+	if err := json.Unmarshal(data, &mapClaims); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	mapClaims["iss"] = v.issuer
+	mapClaims["aud"] = v.audience
+	mapClaims["sub"] = subject
+	mapClaims["iat"] = now.Unix()
+	mapClaims["exp"] = now.Add(ttl).Unix()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, mapClaims)
+	token.Header["kid"] = v.keyID
+
+	return token.SignedString(v.privateKey)
+}
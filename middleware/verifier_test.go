@@ -0,0 +1,211 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"gopkg.in/square/go-jose.v2"
+)
+
+const localIssuer = "http://localhost:8080/"
+
+// writeFile writes data to a file of the test's temporary directory.
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+
+	return writeFile(t, "key.pem", pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}))
+}
+
+// validatedClaims validates token with v and returns its custom claims.
+func validatedClaims(t *testing.T, v TokenVerifier, token string) (*validator.ValidatedClaims, *CustomClaims) {
+	t.Helper()
+
+	claims, err := v.ValidateToken(context.Background(), token)
+	if err != nil {
+		t.Fatalf("token rejected: %v", err)
+	}
+	validated := claims.(*validator.ValidatedClaims)
+	return validated, validated.CustomClaims.(*CustomClaims)
+}
+
+func TestLocalVerifierRoundTrip(t *testing.T) {
+	v, err := NewLocalVerifier(localIssuer, testAudience, newRSAKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := v.Mint("auth0|alice", CustomClaims{
+		Permissions: []string{"read:book"},
+		Roles:       []string{"editor"},
+		OrgID:       "o1",
+		AMR:         []string{"mfa"},
+	}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, customClaims := validatedClaims(t, v, token)
+	if claims.RegisteredClaims.Subject != "auth0|alice" || claims.RegisteredClaims.Issuer != localIssuer {
+		t.Errorf("registered claims = %+v", claims.RegisteredClaims)
+	}
+	if customClaims.OrgID != "o1" || len(customClaims.Permissions) != 1 || len(customClaims.Roles) != 1 || len(customClaims.AMR) != 1 {
+		t.Errorf("custom claims = %+v, want them as minted", customClaims)
+	}
+
+	t.Run("expired", func(t *testing.T) {
+		token, err := v.Mint("auth0|alice", CustomClaims{}, -time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := v.ValidateToken(context.Background(), token); err == nil {
+			t.Error("expired token accepted")
+		}
+	})
+
+	t.Run("another key", func(t *testing.T) {
+		other, err := NewLocalVerifier(localIssuer, testAudience, newRSAKey(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := other.ValidateToken(context.Background(), token); err == nil {
+			t.Error("token of another key accepted")
+		}
+	})
+
+	t.Run("another audience", func(t *testing.T) {
+		other, err := NewLocalVerifier(localIssuer, "https://other.example.com", v.privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := other.ValidateToken(context.Background(), token); err == nil {
+			t.Error("token of another audience accepted")
+		}
+	})
+}
+
+func TestNewLocalVerifierFromPEM(t *testing.T) {
+	key := newRSAKey(t)
+	signer, err := NewLocalVerifier(localIssuer, testAudience, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := signer.Mint("auth0|alice", CustomClaims{}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "local issuer"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		canSign bool
+	}{
+		{"pkcs1 private key", writePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key)), true},
+		{"pkcs8 private key", writePEM(t, "PRIVATE KEY", pkcs8), true},
+		{"public key", writePEM(t, "PUBLIC KEY", public), false},
+		{"certificate", writePEM(t, "CERTIFICATE", cert), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewLocalVerifierFromPEM(localIssuer, testAudience, tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.CanSign() != tt.canSign {
+				t.Errorf("CanSign() = %t, want %t", v.CanSign(), tt.canSign)
+			}
+			validatedClaims(t, v, token)
+
+			if !tt.canSign {
+				if _, err := v.Mint("auth0|alice", CustomClaims{}, time.Hour); err == nil {
+					t.Error("minted without a private key")
+				}
+			}
+		})
+	}
+
+	t.Run("not a key", func(t *testing.T) {
+		if _, err := NewLocalVerifierFromPEM(localIssuer, testAudience, writeFile(t, "key.pem", []byte("not a key"))); err == nil {
+			t.Error("loaded a file without PEM data")
+		}
+		if _, err := NewLocalVerifierFromPEM(localIssuer, testAudience, writePEM(t, "EC PRIVATE KEY", []byte{1})); err == nil {
+			t.Error("loaded an unsupported PEM block")
+		}
+	})
+}
+
+func TestNewLocalVerifierFromJWKS(t *testing.T) {
+	key := newRSAKey(t)
+	signer, err := NewLocalVerifier(localIssuer, testAudience, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := signer.Mint("auth0|alice", CustomClaims{Permissions: []string{"read:book"}}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Publish the key under the kid Mint puts in the token header, as the
+	// JWKS of the tenant does.
+	data, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: &newRSAKey(t).PublicKey, KeyID: "other", Algorithm: "RS256", Use: "sig"},
+		{Key: &key.PublicKey, KeyID: signer.keyID, Algorithm: "RS256", Use: "sig"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := NewLocalVerifierFromJWKS(localIssuer, testAudience, writeFile(t, "jwks.json", data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.CanSign() {
+		t.Error("a JWKS verifier can sign")
+	}
+	if _, customClaims := validatedClaims(t, v, token); len(customClaims.Permissions) != 1 {
+		t.Errorf("permissions = %v, want [read:book]", customClaims.Permissions)
+	}
+
+	if _, err := NewLocalVerifierFromJWKS(localIssuer, testAudience, writeFile(t, "jwks.json", []byte("{"))); err == nil {
+		t.Error("loaded an invalid JWKS")
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/fahmiyonda007/go-gin-gorm/migrations"
+	"github.com/fahmiyonda007/go-gin-gorm/models"
+)
+
+const migrateUsage = "usage: migrate up | down [steps] | status"
+
+// runMigrate runs the migrate subcommand with its arguments.
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		log.Fatal(migrateUsage)
+	}
+
+	models.ConnectDatabase()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrations.Up(models.DB)
+		for _, migration := range applied {
+			fmt.Printf("applied  %04d %s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatalf("Error migrating up: %v", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				log.Fatal(migrateUsage)
+			}
+			steps = n
+		}
+
+		reverted, err := migrations.Down(models.DB, steps)
+		for _, migration := range reverted {
+			fmt.Printf("reverted %04d %s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatalf("Error migrating down: %v", err)
+		}
+	case "status":
+		states, err := migrations.Status(models.DB)
+		if err != nil {
+			log.Fatalf("Error reading the migrations: %v", err)
+		}
+		for _, state := range states {
+			applied := "pending"
+			if state.AppliedAt != nil {
+				applied = "applied " + state.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d %-30s %s\n", state.Version, state.Name, applied)
+		}
+	default:
+		log.Fatal(migrateUsage)
+	}
+}
+
+// requireMigrated stops the service when migrations are pending, rather
+// than serving requests against a schema the code doesn't expect. An
+// in-memory database starts empty every time and is migrated instead.
+func requireMigrated() {
+	config, err := models.DatabaseConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Error reading the database settings: %v", err)
+	}
+	if config.InMemory() {
+		if _, err := migrations.Up(models.DB); err != nil {
+			log.Fatalf("Error migrating the in-memory database: %v", err)
+		}
+		return
+	}
+
+	pending, err := migrations.Pending(models.DB)
+	if err != nil {
+		log.Fatalf("Error reading the migrations: %v", err)
+	}
+	if len(pending) > 0 {
+		log.Fatalf("The database schema is %d migration(s) behind, run `go run . migrate up` first", len(pending))
+	}
+}
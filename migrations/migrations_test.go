@@ -0,0 +1,91 @@
+package migrations_test
+
+import (
+	"testing"
+
+	"github.com/fahmiyonda007/go-gin-gorm/migrations"
+	"github.com/fahmiyonda007/go-gin-gorm/models"
+)
+
+// baselineAuthor and baselineBook are the models as the former AutoMigrate
+// created them, before owners and organizations existed.
+type baselineAuthor struct {
+	ID   uint `gorm:"primary_key"`
+	Name string
+}
+
+func (baselineAuthor) TableName() string { return "authors" }
+
+type baselineBook struct {
+	ID       uint `gorm:"primary_key"`
+	Title    string
+	AuthorId uint
+	Author   baselineAuthor `gorm:"foreignKey:AuthorId;references:ID"`
+}
+
+func (baselineBook) TableName() string { return "books" }
+
+func TestUpAdoptsBaselineDatabase(t *testing.T) {
+	db, err := models.Open(models.DatabaseConfig{Driver: "sqlite", Name: ":memory:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+
+	if err := db.AutoMigrate(&baselineBook{}, &baselineAuthor{}); err != nil {
+		t.Fatal(err)
+	}
+	author := baselineAuthor{Name: "Tolkien"}
+	if err := db.Create(&author).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&baselineBook{Title: "The Hobbit", AuthorId: author.ID}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatalf("Up on a baseline database: %v", err)
+	}
+
+	var books []models.Book
+	if err := db.Preload("Author").Find(&books).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(books) != 1 || books[0].Title != "The Hobbit" || books[0].Author.Name != "Tolkien" {
+		t.Fatalf("books = %+v", books)
+	}
+	if books[0].OrgID != "" || books[0].Author.OrgID != "" {
+		t.Errorf("org = %q / %q, want the default", books[0].OrgID, books[0].Author.OrgID)
+	}
+
+	pending, err := migrations.Pending(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("%d migrations still pending", len(pending))
+	}
+}
+
+func TestUpAdoptsOwnedDatabase(t *testing.T) {
+	db, err := models.Open(models.DatabaseConfig{Driver: "sqlite", Name: ":memory:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+
+	if err := db.AutoMigrate(&models.Book{}, &models.Author{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatalf("Up on an AutoMigrate database: %v", err)
+	}
+}
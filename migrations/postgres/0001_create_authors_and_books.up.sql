@@ -0,0 +1,26 @@
+-- IF NOT EXISTS lets databases created by the former AutoMigrate adopt
+-- the migrations. Those made before owners and organizations existed
+-- lack owner_sub and org_id, which are added to them.
+CREATE TABLE IF NOT EXISTS authors (
+    id bigserial PRIMARY KEY,
+    name text,
+    owner_sub text,
+    org_id text NOT NULL DEFAULT ''
+);
+ALTER TABLE authors ADD COLUMN IF NOT EXISTS owner_sub text;
+ALTER TABLE authors ADD COLUMN IF NOT EXISTS org_id text NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_authors_owner_sub ON authors (owner_sub);
+CREATE INDEX IF NOT EXISTS idx_authors_org_id ON authors (org_id);
+
+CREATE TABLE IF NOT EXISTS books (
+    id bigserial PRIMARY KEY,
+    title text,
+    author_id bigint,
+    owner_sub text,
+    org_id text NOT NULL DEFAULT '',
+    CONSTRAINT fk_books_author FOREIGN KEY (author_id) REFERENCES authors (id)
+);
+ALTER TABLE books ADD COLUMN IF NOT EXISTS owner_sub text;
+ALTER TABLE books ADD COLUMN IF NOT EXISTS org_id text NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_books_owner_sub ON books (owner_sub);
+CREATE INDEX IF NOT EXISTS idx_books_org_id ON books (org_id);
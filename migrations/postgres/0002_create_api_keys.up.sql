@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id bigserial PRIMARY KEY,
+    name text,
+    prefix text,
+    key_hash text NOT NULL,
+    permissions text,
+    expires_at timestamptz,
+    last_used_at timestamptz,
+    created_at timestamptz,
+    owner_sub text,
+    org_id text NOT NULL DEFAULT ''
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_key_hash ON api_keys (key_hash);
+CREATE INDEX IF NOT EXISTS idx_api_keys_org_id ON api_keys (org_id);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+    id bigserial PRIMARY KEY,
+    type text NOT NULL,
+    sub text,
+    ip text,
+    method text,
+    route text,
+    permission text,
+    reason text,
+    org_id text NOT NULL DEFAULT '',
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_audit_events_type ON audit_events (type);
+CREATE INDEX IF NOT EXISTS idx_audit_events_sub ON audit_events (sub);
+CREATE INDEX IF NOT EXISTS idx_audit_events_org_id ON audit_events (org_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events (created_at);
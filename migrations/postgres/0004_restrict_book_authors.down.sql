@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_books_author_id;
+ALTER TABLE books DROP CONSTRAINT IF EXISTS fk_books_author;
+ALTER TABLE books ADD CONSTRAINT fk_books_author
+    FOREIGN KEY (author_id) REFERENCES authors (id);
@@ -0,0 +1,9 @@
+-- Books whose author is gone keep no dangling reference, the constraint
+-- could not be validated otherwise.
+UPDATE books SET author_id = NULL
+WHERE author_id IS NOT NULL AND author_id NOT IN (SELECT id FROM authors);
+
+ALTER TABLE books DROP CONSTRAINT IF EXISTS fk_books_author;
+ALTER TABLE books ADD CONSTRAINT fk_books_author
+    FOREIGN KEY (author_id) REFERENCES authors (id) ON DELETE RESTRICT;
+CREATE INDEX IF NOT EXISTS idx_books_author_id ON books (author_id);
@@ -0,0 +1,22 @@
+-- IF NOT EXISTS lets databases created by the former AutoMigrate adopt
+-- the migrations. SQLite has no ADD COLUMN IF NOT EXISTS, so the owner_sub
+-- and org_id columns missing from older ones are added by Up beforehand.
+CREATE TABLE IF NOT EXISTS authors (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    name text,
+    owner_sub text,
+    org_id text NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_authors_owner_sub ON authors (owner_sub);
+CREATE INDEX IF NOT EXISTS idx_authors_org_id ON authors (org_id);
+
+CREATE TABLE IF NOT EXISTS books (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    title text,
+    author_id integer,
+    owner_sub text,
+    org_id text NOT NULL DEFAULT '',
+    CONSTRAINT fk_books_author FOREIGN KEY (author_id) REFERENCES authors (id)
+);
+CREATE INDEX IF NOT EXISTS idx_books_owner_sub ON books (owner_sub);
+CREATE INDEX IF NOT EXISTS idx_books_org_id ON books (org_id);
@@ -0,0 +1,15 @@
+CREATE TABLE books_old (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    title text,
+    author_id integer,
+    owner_sub text,
+    org_id text NOT NULL DEFAULT '',
+    CONSTRAINT fk_books_author FOREIGN KEY (author_id) REFERENCES authors (id)
+);
+INSERT INTO books_old (id, title, author_id, owner_sub, org_id)
+SELECT id, title, author_id, owner_sub, org_id FROM books;
+DROP TABLE books;
+ALTER TABLE books_old RENAME TO books;
+
+CREATE INDEX idx_books_owner_sub ON books (owner_sub);
+CREATE INDEX idx_books_org_id ON books (org_id);
@@ -0,0 +1,22 @@
+-- Books whose author is gone keep no dangling reference, they could not be
+-- copied into the constrained table otherwise.
+UPDATE books SET author_id = NULL
+WHERE author_id IS NOT NULL AND author_id NOT IN (SELECT id FROM authors);
+
+-- SQLite can't alter a constraint, so the table is rebuilt.
+CREATE TABLE books_new (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    title text,
+    author_id integer,
+    owner_sub text,
+    org_id text NOT NULL DEFAULT '',
+    CONSTRAINT fk_books_author FOREIGN KEY (author_id) REFERENCES authors (id) ON DELETE RESTRICT
+);
+INSERT INTO books_new (id, title, author_id, owner_sub, org_id)
+SELECT id, title, author_id, owner_sub, org_id FROM books;
+DROP TABLE books;
+ALTER TABLE books_new RENAME TO books;
+
+CREATE INDEX idx_books_owner_sub ON books (owner_sub);
+CREATE INDEX idx_books_org_id ON books (org_id);
+CREATE INDEX idx_books_author_id ON books (author_id);
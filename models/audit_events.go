@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// Types of AuditEvent.
+const (
+	AuditLoginSucceeded   = "login_succeeded"
+	AuditLoginFailed      = "login_failed"
+	AuditTokenRejected    = "token_rejected"
+	AuditPermissionDenied = "permission_denied"
+)
+
+// AuditEvent records an authentication or authorization decision.
+type AuditEvent struct {
+	ID         uint      `json:"id" gorm:"primary_key"`
+	Type       string    `json:"type" gorm:"index;not null"`
+	Sub        string    `json:"sub" gorm:"index"`
+	IP         string    `json:"ip"`
+	Method     string    `json:"method"`
+	Route      string    `json:"route"`
+	Permission string    `json:"permission,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	OrgID      string    `json:"orgId,omitempty" gorm:"index;not null;default:''"`
+	CreatedAt  time.Time `json:"createdAt" gorm:"index"`
+}
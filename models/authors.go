@@ -1,6 +1,8 @@
 package models
 
 type Author struct {
-	ID   uint   `json:"id" gorm:"primary_key"`
-	Name string `json:"name"`
+	ID       uint   `json:"id" gorm:"primary_key"`
+	Name     string `json:"name"`
+	OwnerSub string `json:"ownerSub" gorm:"index"`
+	OrgID    string `json:"orgId,omitempty" gorm:"index;not null;default:''"`
 }
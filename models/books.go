@@ -5,4 +5,6 @@ type Book struct {
 	Title    string `json:"title"`
 	AuthorId uint   `json:"-" gorm:"foreignKey:AuthorId;references:ID"`
 	Author   Author `json:"author"`
+	OwnerSub string `json:"ownerSub" gorm:"index"`
+	OrgID    string `json:"orgId,omitempty" gorm:"index;not null;default:''"`
 }
@@ -0,0 +1,10 @@
+package models
+
+import "gorm.io/gorm"
+
+// OwnedBy limits a query to the records created by the user sub.
+func OwnedBy(sub string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("owner_sub = ?", sub)
+	}
+}
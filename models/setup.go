@@ -3,25 +3,178 @@
 package models
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 var DB *gorm.DB
 
-func ConnectDatabase() {
+// DatabaseConfig describes the database to connect to. With the sqlite
+// driver Name is the path of the database file, ":memory:" included, and
+// the server settings are ignored.
+type DatabaseConfig struct {
+	Driver   string
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
+	SSLMode  string
+	TimeZone string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// InMemory reports whether config is an in-memory SQLite database, which
+// starts empty on every run.
+func (config DatabaseConfig) InMemory() bool {
+	return config.Driver == "sqlite" && config.Name == ":memory:"
+}
+
+// DatabaseConfigFromEnv reads the DB_* settings. Unset ones default to a
+// local Postgres server.
+func DatabaseConfigFromEnv() (DatabaseConfig, error) {
+	config := DatabaseConfig{
+		Driver:   getenv("DB_DRIVER", "postgres"),
+		Host:     getenv("DB_HOST", "localhost"),
+		Port:     getenv("DB_PORT", "5432"),
+		User:     getenv("DB_USER", "postgres"),
+		Password: getenv("DB_PASSWORD", "postgres"),
+		Name:     getenv("DB_NAME", "gorm"),
+		SSLMode:  getenv("DB_SSLMODE", "disable"),
+		TimeZone: getenv("DB_TIMEZONE", "Asia/Jakarta"),
+	}
+
+	var err error
+	if config.MaxOpenConns, err = strconv.Atoi(getenv("DB_MAX_OPEN_CONNS", "0")); err != nil {
+		return config, fmt.Errorf("invalid DB_MAX_OPEN_CONNS: %w", err)
+	}
+	if config.MaxIdleConns, err = strconv.Atoi(getenv("DB_MAX_IDLE_CONNS", "2")); err != nil {
+		return config, fmt.Errorf("invalid DB_MAX_IDLE_CONNS: %w", err)
+	}
+	if config.ConnMaxLifetime, err = time.ParseDuration(getenv("DB_CONN_MAX_LIFETIME", "0s")); err != nil {
+		return config, fmt.Errorf("invalid DB_CONN_MAX_LIFETIME: %w", err)
+	}
+
+	return config, nil
+}
+
+// postgresDSN builds the keyword/value connection string of config. The
+// values are quoted, so a space, quote or backslash in a password can't
+// change its meaning. The driver reads TimeZone back from the string as
+// is, so it stays unquoted and may not contain such characters.
+func (config DatabaseConfig) postgresDSN() (string, error) {
+	if strings.ContainsAny(config.TimeZone, " '\\&") {
+		return "", fmt.Errorf("invalid DB_TIMEZONE %q", config.TimeZone)
+	}
 
-	dsn := "host=localhost user=postgres password=postgres dbname=gorm port=5432 sslmode=disable TimeZone=Asia/Jakarta"
-	database, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	quote := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	var dsn strings.Builder
+	for _, setting := range [][2]string{
+		{"host", config.Host},
+		{"port", config.Port},
+		{"user", config.User},
+		{"password", config.Password},
+		{"dbname", config.Name},
+		{"sslmode", config.SSLMode},
+	} {
+		fmt.Fprintf(&dsn, "%s='%s' ", setting[0], quote.Replace(setting[1]))
+	}
+	dsn.WriteString("TimeZone=" + config.TimeZone)
 
+	return dsn.String(), nil
+}
+
+// Open connects to the database of config, with the tenant callbacks
+// registered and the pool limits applied.
+func Open(config DatabaseConfig) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch config.Driver {
+	case "postgres":
+		dsn, err := config.postgresDSN()
+		if err != nil {
+			return nil, err
+		}
+		dialector = postgres.Open(dsn)
+	case "sqlite":
+		// SQLite only enforces foreign keys when asked to, per connection.
+		dsn := config.Name
+		if strings.Contains(dsn, "?") {
+			dsn += "&_pragma=foreign_keys(1)"
+		} else {
+			dsn += "?_pragma=foreign_keys(1)"
+		}
+		dialector = sqlite.Open(dsn)
+	default:
+		return nil, fmt.Errorf("unknown DB_DRIVER %q", config.Driver)
+	}
+
+	database, err := gorm.Open(dialector, &gorm.Config{})
 	if err != nil {
-		panic("Failed to connect to database!")
+		return nil, err
+	}
+
+	if config.Driver == "sqlite" {
+		var enabled bool
+		if err := database.Raw("PRAGMA foreign_keys").Scan(&enabled).Error; err != nil {
+			return nil, err
+		}
+		if !enabled {
+			return nil, errors.New("SQLite doesn't enforce foreign keys")
+		}
+	}
+
+	if err := registerTenantCallbacks(database); err != nil {
+		return nil, err
 	}
 
-	err = database.AutoMigrate(&Book{}, &Author{})
+	sqlDB, err := database.DB()
 	if err != nil {
-		return
+		return nil, err
 	}
+	// Every connection to an in-memory SQLite database gets a database of
+	// its own, so stick to one and keep it open.
+	if config.InMemory() {
+		config.MaxOpenConns = 1
+		config.MaxIdleConns = 1
+		config.ConnMaxLifetime = 0
+	}
+	sqlDB.SetMaxOpenConns(config.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(config.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
+
+	return database, nil
+}
+
+func ConnectDatabase() {
 
+	config, err := DatabaseConfigFromEnv()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	database, err := Open(config)
+	if err != nil {
+		panic("Failed to connect to database: " + err.Error())
+	}
+
+	// The schema is managed by the migrations package, see `migrate`.
 	DB = database
 }
+
+func getenv(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestPostgresDSNQuotesValues(t *testing.T) {
+	config := DatabaseConfig{
+		Host:     "db.example.com",
+		Port:     "5433",
+		User:     "app user",
+		Password: `it's a \secret sslmode=disable`,
+		Name:     "gorm",
+		SSLMode:  "require",
+		TimeZone: "Asia/Jakarta",
+	}
+
+	dsn, err := config.postgresDSN()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("parse %q: %v", dsn, err)
+	}
+
+	if parsed.Host != config.Host || parsed.Port != 5433 {
+		t.Errorf("address = %s:%d", parsed.Host, parsed.Port)
+	}
+	if parsed.User != config.User || parsed.Password != config.Password {
+		t.Errorf("credentials = %q / %q", parsed.User, parsed.Password)
+	}
+	if parsed.Database != config.Name {
+		t.Errorf("database = %q", parsed.Database)
+	}
+	if parsed.TLSConfig == nil {
+		t.Error("sslmode=require was overridden")
+	}
+	if tz := parsed.RuntimeParams["TimeZone"]; tz != config.TimeZone {
+		t.Errorf("TimeZone = %q", tz)
+	}
+}
+
+func TestPostgresDSNRejectsTimeZoneSeparators(t *testing.T) {
+	for _, timeZone := range []string{"Asia/Jakarta sslmode=disable", "UTC'", `UTC\`, "UTC&x=y"} {
+		config := DatabaseConfig{TimeZone: timeZone}
+		if _, err := config.postgresDSN(); err == nil {
+			t.Errorf("TimeZone %q accepted", timeZone)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"context"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type tenantKey struct{}
+
+// WithTenant returns a context whose GORM queries only see and create
+// records of the organization orgID. An empty orgID is the tenant of
+// callers that don't belong to any organization.
+func WithTenant(ctx context.Context, orgID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, orgID)
+}
+
+// TenantFromContext returns the tenant set by WithTenant, if any.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	orgID, ok := ctx.Value(tenantKey{}).(string)
+	return orgID, ok
+}
+
+// registerTenantCallbacks scopes every statement on a model with an OrgID
+// field to the tenant of its context, so a controller can't forget to. A
+// record of another tenant is simply not found.
+func registerTenantCallbacks(db *gorm.DB) error {
+	callbacks := db.Callback()
+
+	if err := callbacks.Create().Before("gorm:create").Register("tenant:create", assignTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("gorm:query").Register("tenant:query", scopeTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenant:update", scopeTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("tenant:delete", scopeTenant); err != nil {
+		return err
+	}
+	return callbacks.Row().Before("gorm:row").Register("tenant:row", scopeTenant)
+}
+
+func scopeTenant(db *gorm.DB) {
+	orgID, ok := TenantFromContext(db.Statement.Context)
+	if !ok || db.Statement.Schema == nil {
+		return
+	}
+
+	field := db.Statement.Schema.LookUpField("OrgID")
+	if field == nil {
+		return
+	}
+
+	condition := clause.Eq{Column: clause.Column{Table: db.Statement.Table, Name: field.DBName}, Value: orgID}
+
+	// Chained finishers share the statement, only add the condition once.
+	if where, ok := db.Statement.Clauses["WHERE"].Expression.(clause.Where); ok {
+		for _, expr := range where.Exprs {
+			if expr == clause.Expression(condition) {
+				return
+			}
+		}
+	}
+
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{condition}})
+}
+
+func assignTenant(db *gorm.DB) {
+	orgID, ok := TenantFromContext(db.Statement.Context)
+	if !ok || db.Statement.Schema == nil {
+		return
+	}
+
+	field := db.Statement.Schema.LookUpField("OrgID")
+	if field == nil {
+		return
+	}
+
+	switch db.Statement.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < db.Statement.ReflectValue.Len(); i++ {
+			if err := field.Set(db.Statement.Context, db.Statement.ReflectValue.Index(i), orgID); err != nil {
+				db.AddError(err)
+			}
+		}
+	case reflect.Struct:
+		if err := field.Set(db.Statement.Context, db.Statement.ReflectValue, orgID); err != nil {
+			db.AddError(err)
+		}
+	}
+}
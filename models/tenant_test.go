@@ -0,0 +1,121 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fahmiyonda007/go-gin-gorm/migrations"
+	"gorm.io/gorm"
+)
+
+// openTestDB opens a migrated in-memory SQLite database.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := Open(DatabaseConfig{Driver: "sqlite", Name: ":memory:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestTenantCreateStampsOrg(t *testing.T) {
+	db := openTestDB(t)
+
+	author := Author{Name: "Pramoedya", OrgID: "o2"}
+	if err := db.WithContext(WithTenant(context.Background(), "o1")).Create(&author).Error; err != nil {
+		t.Fatal(err)
+	}
+	if author.OrgID != "o1" {
+		t.Errorf("OrgID = %q, want o1", author.OrgID)
+	}
+
+	books := []Book{{Title: "Bumi Manusia", AuthorId: author.ID}, {Title: "Anak Semua Bangsa", AuthorId: author.ID, OrgID: "o2"}}
+	if err := db.WithContext(WithTenant(context.Background(), "o1")).Create(&books).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, book := range books {
+		if book.OrgID != "o1" {
+			t.Errorf("OrgID of %q = %q, want o1", book.Title, book.OrgID)
+		}
+	}
+
+	var stored Author
+	if err := db.First(&stored, author.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.OrgID != "o1" {
+		t.Errorf("stored OrgID = %q, want o1", stored.OrgID)
+	}
+}
+
+func TestTenantIsolation(t *testing.T) {
+	db := openTestDB(t)
+	o1 := db.WithContext(WithTenant(context.Background(), "o1"))
+	o2 := db.WithContext(WithTenant(context.Background(), "o2"))
+
+	author := Author{Name: "Pramoedya"}
+	if err := o1.Create(&author).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("get", func(t *testing.T) {
+		var found Author
+		if err := o2.First(&found, author.ID).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("First from o2 = %v, want record not found", err)
+		}
+
+		var count int64
+		if err := o2.Model(&Author{}).Count(&count).Error; err != nil || count != 0 {
+			t.Errorf("Count from o2 = %d, %v, want 0", count, err)
+		}
+
+		if err := o1.First(&found, author.ID).Error; err != nil {
+			t.Errorf("First from o1 = %v", err)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		result := o2.Model(&Author{ID: author.ID}).Update("name", "Stolen")
+		if result.Error != nil || result.RowsAffected != 0 {
+			t.Errorf("Update from o2 affected %d rows, %v, want 0", result.RowsAffected, result.Error)
+		}
+
+		var stored Author
+		if err := db.First(&stored, author.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if stored.Name != "Pramoedya" {
+			t.Errorf("name = %q, want Pramoedya", stored.Name)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		result := o2.Delete(&Author{}, author.ID)
+		if result.Error != nil || result.RowsAffected != 0 {
+			t.Errorf("Delete from o2 affected %d rows, %v, want 0", result.RowsAffected, result.Error)
+		}
+
+		var count int64
+		if err := db.Model(&Author{}).Where("id = ?", author.ID).Count(&count).Error; err != nil || count != 1 {
+			t.Errorf("author count = %d, %v, want 1", count, err)
+		}
+	})
+
+	t.Run("no tenant", func(t *testing.T) {
+		var found Author
+		if err := db.WithContext(WithTenant(context.Background(), "")).First(&found, author.ID).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("First without an org = %v, want record not found", err)
+		}
+	})
+}
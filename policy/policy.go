@@ -0,0 +1,239 @@
+// Package policy maps roles to book and author permissions from a local
+// YAML or JSON file, so who may do what can change without touching Auth0
+// or the routes.
+//
+// A policy file looks like
+//
+//	roles:
+//	  reader:
+//	    permissions: ["read:*"]
+//	  editor:
+//	    inherits: [reader]
+//	    permissions: ["create:book", "update:book", "*:author"]
+//	  admin:
+//	    permissions: ["*"]
+//
+// Permissions are "action:resource" pairs in which either side may be the
+// wildcard "*"; a lone "*" grants everything.
+package policy
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is the layout of a policy file. JSON files work too since YAML is a
+// superset of JSON.
+type File struct {
+	Roles map[string]Role `yaml:"roles" json:"roles"`
+}
+
+// Role grants permissions directly and through the roles it inherits.
+type Role struct {
+	Inherits    []string `yaml:"inherits" json:"inherits"`
+	Permissions []string `yaml:"permissions" json:"permissions"`
+}
+
+// Engine answers whether a set of roles and permissions satisfies a
+// required permission. A nil *Engine has no roles and only matches the
+// permissions themselves, wildcards included.
+type Engine struct {
+	path    string
+	mu      sync.RWMutex
+	roles   map[string][]string
+	modTime time.Time
+}
+
+// Load reads the policy file at path.
+func Load(path string) (*Engine, error) {
+	e := &Engine{path: path}
+	if err := e.reload(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// New builds an Engine from an already parsed policy.
+func New(file File) (*Engine, error) {
+	roles, err := resolve(file)
+	if err != nil {
+		return nil, err
+	}
+	return &Engine{roles: roles}, nil
+}
+
+// Allowed reports whether holding roles and permissions grants required.
+func (e *Engine) Allowed(roles []string, permissions []string, required string) bool {
+	for _, permission := range permissions {
+		if Match(permission, required) {
+			return true
+		}
+	}
+
+	if e == nil {
+		return false
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	for _, role := range roles {
+		for _, permission := range e.roles[role] {
+			if Match(permission, required) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// HasRole reports whether the policy defines the role name.
+func (e *Engine) HasRole(name string) bool {
+	if e == nil {
+		return false
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	_, ok := e.roles[name]
+	return ok
+}
+
+// Watch reloads the policy file whenever its modification time changes,
+// checking every interval until ctx is done. A file that fails to load is
+// logged and the previous policy stays in effect.
+func (e *Engine) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(e.path)
+			if err != nil {
+				log.Printf("Error checking the policy file: %v", err)
+				continue
+			}
+
+			e.mu.RLock()
+			changed := !info.ModTime().Equal(e.modTime)
+			e.mu.RUnlock()
+
+			if !changed {
+				continue
+			}
+
+			if err := e.reload(); err != nil {
+				log.Printf("Error reloading the policy file, keeping the previous policy: %v", err)
+				continue
+			}
+			log.Printf("Reloaded the policy file %s", e.path)
+		}
+	}
+}
+
+func (e *Engine) reload() error {
+	info, err := os.Stat(e.path)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(e.path)
+	if err != nil {
+		return err
+	}
+
+	var file File
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("could not parse policy %s: %w", e.path, err)
+	}
+
+	roles, err := resolve(file)
+	if err != nil {
+		return fmt.Errorf("invalid policy %s: %w", e.path, err)
+	}
+
+	e.mu.Lock()
+	e.roles = roles
+	e.modTime = info.ModTime()
+	e.mu.Unlock()
+
+	return nil
+}
+
+// resolve flattens role inheritance into the full permission list of
+// every role, rejecting unknown parents and cycles.
+func resolve(file File) (map[string][]string, error) {
+	resolved := map[string][]string{}
+	visiting := map[string]bool{}
+
+	var visit func(name string) ([]string, error)
+	visit = func(name string) ([]string, error) {
+		if permissions, ok := resolved[name]; ok {
+			return permissions, nil
+		}
+
+		role, ok := file.Roles[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown role %q", name)
+		}
+
+		if visiting[name] {
+			return nil, fmt.Errorf("role %q inherits from itself", name)
+		}
+		visiting[name] = true
+
+		permissions := append([]string{}, role.Permissions...)
+		for _, parent := range role.Inherits {
+			inherited, err := visit(parent)
+			if err != nil {
+				return nil, err
+			}
+			permissions = append(permissions, inherited...)
+		}
+
+		visiting[name] = false
+		resolved[name] = permissions
+		return permissions, nil
+	}
+
+	for name := range file.Roles {
+		if _, err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return resolved, nil
+}
+
+// Match reports whether the granted permission pattern covers required.
+// Both are "action:resource" pairs and either side of pattern may be "*".
+func Match(pattern string, required string) bool {
+	if pattern == "*" || pattern == required {
+		return true
+	}
+
+	patternAction, patternResource, ok := strings.Cut(pattern, ":")
+	if !ok {
+		return false
+	}
+
+	action, resource, ok := strings.Cut(required, ":")
+	if !ok {
+		return false
+	}
+
+	return (patternAction == "*" || patternAction == action) &&
+		(patternResource == "*" || patternResource == resource)
+}
@@ -0,0 +1,171 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		required string
+		want     bool
+	}{
+		{"*", "delete:book", true},
+		{"*", "admin", true},
+		{"read:book", "read:book", true},
+		{"read:book", "read:author", false},
+		{"read:*", "read:book", true},
+		{"read:*", "read:author", true},
+		{"read:*", "update:book", false},
+		{"*:book", "delete:book", true},
+		{"*:book", "delete:author", false},
+		{"*:*", "update:author", true},
+		{"admin", "admin", true},
+		{"read:*", "admin", false},
+		{"read", "read:book", false},
+		{"", "read:book", false},
+	}
+
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.required); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.required, got, tt.want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name    string
+		roles   map[string]Role
+		role    string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:  "own permissions",
+			roles: map[string]Role{"reader": {Permissions: []string{"read:*"}}},
+			role:  "reader",
+			want:  []string{"read:*"},
+		},
+		{
+			name: "inherited permissions",
+			roles: map[string]Role{
+				"reader": {Permissions: []string{"read:*"}},
+				"editor": {Inherits: []string{"reader"}, Permissions: []string{"update:*"}},
+				"admin":  {Inherits: []string{"editor"}, Permissions: []string{"delete:*"}},
+			},
+			role: "admin",
+			want: []string{"delete:*", "update:*", "read:*"},
+		},
+		{
+			name: "diamond",
+			roles: map[string]Role{
+				"reader":  {Permissions: []string{"read:*"}},
+				"books":   {Inherits: []string{"reader"}, Permissions: []string{"*:book"}},
+				"authors": {Inherits: []string{"reader"}, Permissions: []string{"*:author"}},
+				"editor":  {Inherits: []string{"books", "authors"}},
+			},
+			role: "editor",
+			want: []string{"*:book", "read:*", "*:author", "read:*"},
+		},
+		{
+			name:    "unknown parent",
+			roles:   map[string]Role{"editor": {Inherits: []string{"reader"}}},
+			wantErr: `unknown role "reader"`,
+		},
+		{
+			name:    "inherits itself",
+			roles:   map[string]Role{"editor": {Inherits: []string{"editor"}}},
+			wantErr: "inherits from itself",
+		},
+		{
+			name: "cycle",
+			roles: map[string]Role{
+				"a": {Inherits: []string{"b"}},
+				"b": {Inherits: []string{"c"}},
+				"c": {Inherits: []string{"a"}},
+			},
+			wantErr: "inherits from itself",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, err := resolve(File{Roles: tt.roles})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := strings.Join(resolved[tt.role], ","); got != strings.Join(tt.want, ",") {
+				t.Errorf("permissions of %s = %s, want %s", tt.role, got, strings.Join(tt.want, ","))
+			}
+		})
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	engine, err := New(File{Roles: map[string]Role{
+		"reader": {Permissions: []string{"read:*"}},
+		"editor": {Inherits: []string{"reader"}, Permissions: []string{"create:book"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		engine      *Engine
+		roles       []string
+		permissions []string
+		required    string
+		want        bool
+	}{
+		{"inherited through a role", engine, []string{"editor"}, nil, "read:author", true},
+		{"granted by a role", engine, []string{"editor"}, nil, "create:book", true},
+		{"not granted", engine, []string{"editor"}, nil, "delete:book", false},
+		{"unknown role", engine, []string{"owner"}, nil, "read:book", false},
+		{"token permission", engine, nil, []string{"delete:book"}, "delete:book", true},
+		{"nil engine ignores roles", nil, []string{"editor"}, nil, "read:book", false},
+		{"nil engine expands wildcards", nil, nil, []string{"*:book"}, "delete:book", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.engine.Allowed(tt.roles, tt.permissions, tt.required); got != tt.want {
+				t.Errorf("Allowed(%v, %v, %q) = %v, want %v", tt.roles, tt.permissions, tt.required, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	policy := "roles:\n  reader:\n    permissions: [\"read:*\"]\n"
+	if err := os.WriteFile(path, []byte(policy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	engine, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !engine.Allowed([]string{"reader"}, nil, "read:book") {
+		t.Error("reader can't read:book")
+	}
+
+	if err := os.WriteFile(path, []byte("roles:\n  a:\n    inherits: [a]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load accepted a cyclic policy")
+	}
+}
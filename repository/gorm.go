@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fahmiyonda007/go-gin-gorm/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormBookRepository is a BookRepository on a GORM database.
+type GormBookRepository struct {
+	db *gorm.DB
+}
+
+// NewGormBookRepository creates a GormBookRepository on db.
+func NewGormBookRepository(db *gorm.DB) *GormBookRepository {
+	return &GormBookRepository{db: db}
+}
+
+func (r *GormBookRepository) List(ctx context.Context, options ListOptions) ([]models.Book, int64, error) {
+	query := conn(ctx, r.db).Model(&models.Book{})
+	if options.OwnerSub != "" {
+		query = query.Scopes(models.OwnedBy(options.OwnerSub))
+	}
+	if options.AuthorID != 0 {
+		query = query.Where("author_id = ?", options.AuthorID)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var books []models.Book
+	err := query.Preload("Author").Order("id").Limit(options.Length).Offset(options.offset()).Find(&books).Error
+	return books, count, err
+}
+
+func (r *GormBookRepository) Get(ctx context.Context, id uint) (*models.Book, error) {
+	var book models.Book
+	if err := conn(ctx, r.db).Preload("Author").First(&book, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &book, nil
+}
+
+func (r *GormBookRepository) Create(ctx context.Context, book *models.Book) error {
+	db := conn(ctx, r.db)
+	if err := db.Omit(clause.Associations).Create(book).Error; err != nil {
+		return err
+	}
+	return loadAuthor(db, book)
+}
+
+func (r *GormBookRepository) Update(ctx context.Context, book *models.Book) error {
+	// A book without an author keeps its NULL author_id, 0 references no
+	// author.
+	var omit []string
+	if book.AuthorId == 0 {
+		omit = append(omit, "AuthorId")
+	}
+
+	db := conn(ctx, r.db)
+	if err := updateAll(db, book, omit...); err != nil {
+		return err
+	}
+	return loadAuthor(db, book)
+}
+
+func (r *GormBookRepository) Delete(ctx context.Context, id uint) error {
+	return deleteByID(conn(ctx, r.db), &models.Book{}, id)
+}
+
+func (r *GormBookRepository) CountByAuthor(ctx context.Context, authorID uint) (int64, error) {
+	var count int64
+	err := conn(ctx, r.db).Model(&models.Book{}).Where("author_id = ?", authorID).Count(&count).Error
+	return count, err
+}
+
+// GormAuthorRepository is an AuthorRepository on a GORM database.
+type GormAuthorRepository struct {
+	db *gorm.DB
+}
+
+// NewGormAuthorRepository creates a GormAuthorRepository on db.
+func NewGormAuthorRepository(db *gorm.DB) *GormAuthorRepository {
+	return &GormAuthorRepository{db: db}
+}
+
+func (r *GormAuthorRepository) List(ctx context.Context, options ListOptions) ([]models.Author, int64, error) {
+	query := conn(ctx, r.db).Model(&models.Author{})
+	if options.OwnerSub != "" {
+		query = query.Scopes(models.OwnedBy(options.OwnerSub))
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var authors []models.Author
+	err := query.Order("id").Limit(options.Length).Offset(options.offset()).Find(&authors).Error
+	return authors, count, err
+}
+
+func (r *GormAuthorRepository) Get(ctx context.Context, id uint) (*models.Author, error) {
+	var author models.Author
+	if err := conn(ctx, r.db).First(&author, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &author, nil
+}
+
+func (r *GormAuthorRepository) Create(ctx context.Context, author *models.Author) error {
+	return conn(ctx, r.db).Create(author).Error
+}
+
+func (r *GormAuthorRepository) Update(ctx context.Context, author *models.Author) error {
+	return updateAll(conn(ctx, r.db), author)
+}
+
+func (r *GormAuthorRepository) Delete(ctx context.Context, id uint) error {
+	return deleteByID(conn(ctx, r.db), &models.Author{}, id)
+}
+
+// loadAuthor reads the Author of book, replacing any stale one. Books
+// without an author are left with an empty one.
+func loadAuthor(db *gorm.DB, book *models.Book) error {
+	book.Author = models.Author{}
+	if book.AuthorId == 0 {
+		return nil
+	}
+	return db.First(&book.Author, book.AuthorId).Error
+}
+
+// updateAll writes every field of model but omit, zero values included,
+// unlike Save it never falls back to inserting a record that is gone.
+func updateAll(db *gorm.DB, model interface{}, omit ...string) error {
+	result := db.Model(model).Select("*").Omit(append([]string{clause.Associations}, omit...)...).Updates(model)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func deleteByID(db *gorm.DB, model interface{}, id uint) error {
+	result := db.Delete(model, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+var (
+	_ BookRepository   = (*GormBookRepository)(nil)
+	_ AuthorRepository = (*GormAuthorRepository)(nil)
+	_ Transactor       = (*GormTransactor)(nil)
+)
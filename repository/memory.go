@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/fahmiyonda007/go-gin-gorm/models"
+)
+
+// MemoryAuthorRepository is an AuthorRepository kept in memory, for
+// tests.
+type MemoryAuthorRepository struct {
+	mu      sync.Mutex
+	nextID  uint
+	authors map[uint]models.Author
+}
+
+// NewMemoryAuthorRepository creates an empty MemoryAuthorRepository.
+func NewMemoryAuthorRepository() *MemoryAuthorRepository {
+	return &MemoryAuthorRepository{authors: map[uint]models.Author{}}
+}
+
+func (r *MemoryAuthorRepository) List(ctx context.Context, options ListOptions) ([]models.Author, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var authors []models.Author
+	for _, author := range r.authors {
+		if inTenant(ctx, author.OrgID) && (options.OwnerSub == "" || author.OwnerSub == options.OwnerSub) {
+			authors = append(authors, author)
+		}
+	}
+	sort.Slice(authors, func(i, j int) bool { return authors[i].ID < authors[j].ID })
+
+	return page(authors, options), int64(len(authors)), nil
+}
+
+func (r *MemoryAuthorRepository) Get(ctx context.Context, id uint) (*models.Author, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	author, ok := r.get(ctx, id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &author, nil
+}
+
+func (r *MemoryAuthorRepository) get(ctx context.Context, id uint) (models.Author, bool) {
+	author, ok := r.authors[id]
+	if !ok || !inTenant(ctx, author.OrgID) {
+		return models.Author{}, false
+	}
+	return author, true
+}
+
+func (r *MemoryAuthorRepository) Create(ctx context.Context, author *models.Author) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	author.ID = r.nextID
+	if orgID, ok := models.TenantFromContext(ctx); ok {
+		author.OrgID = orgID
+	}
+	r.authors[author.ID] = *author
+	return nil
+}
+
+func (r *MemoryAuthorRepository) Update(ctx context.Context, author *models.Author) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.get(ctx, author.ID); !ok {
+		return ErrNotFound
+	}
+	r.authors[author.ID] = *author
+	return nil
+}
+
+func (r *MemoryAuthorRepository) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.get(ctx, id); !ok {
+		return ErrNotFound
+	}
+	delete(r.authors, id)
+	return nil
+}
+
+// MemoryBookRepository is a BookRepository kept in memory, for tests. It
+// loads the Author of its books from authors.
+type MemoryBookRepository struct {
+	mu      sync.Mutex
+	nextID  uint
+	books   map[uint]models.Book
+	authors *MemoryAuthorRepository
+}
+
+// NewMemoryBookRepository creates an empty MemoryBookRepository whose
+// books are written by the authors of authors.
+func NewMemoryBookRepository(authors *MemoryAuthorRepository) *MemoryBookRepository {
+	return &MemoryBookRepository{books: map[uint]models.Book{}, authors: authors}
+}
+
+func (r *MemoryBookRepository) List(ctx context.Context, options ListOptions) ([]models.Book, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var books []models.Book
+	for _, book := range r.books {
+		if inTenant(ctx, book.OrgID) && (options.OwnerSub == "" || book.OwnerSub == options.OwnerSub) &&
+			(options.AuthorID == 0 || book.AuthorId == options.AuthorID) {
+			books = append(books, r.withAuthor(ctx, book))
+		}
+	}
+	sort.Slice(books, func(i, j int) bool { return books[i].ID < books[j].ID })
+
+	return page(books, options), int64(len(books)), nil
+}
+
+func (r *MemoryBookRepository) Get(ctx context.Context, id uint) (*models.Book, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	book, ok := r.books[id]
+	if !ok || !inTenant(ctx, book.OrgID) {
+		return nil, ErrNotFound
+	}
+
+	book = r.withAuthor(ctx, book)
+	return &book, nil
+}
+
+func (r *MemoryBookRepository) Create(ctx context.Context, book *models.Book) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	book.ID = r.nextID
+	if orgID, ok := models.TenantFromContext(ctx); ok {
+		book.OrgID = orgID
+	}
+	*book = r.withAuthor(ctx, *book)
+	r.books[book.ID] = *book
+	return nil
+}
+
+func (r *MemoryBookRepository) Update(ctx context.Context, book *models.Book) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.books[book.ID]
+	if !ok || !inTenant(ctx, stored.OrgID) {
+		return ErrNotFound
+	}
+
+	*book = r.withAuthor(ctx, *book)
+	r.books[book.ID] = *book
+	return nil
+}
+
+func (r *MemoryBookRepository) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	book, ok := r.books[id]
+	if !ok || !inTenant(ctx, book.OrgID) {
+		return ErrNotFound
+	}
+	delete(r.books, id)
+	return nil
+}
+
+func (r *MemoryBookRepository) CountByAuthor(ctx context.Context, authorID uint) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int64
+	for _, book := range r.books {
+		if inTenant(ctx, book.OrgID) && book.AuthorId == authorID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *MemoryBookRepository) withAuthor(ctx context.Context, book models.Book) models.Book {
+	book.Author = models.Author{}
+	if author, err := r.authors.Get(ctx, book.AuthorId); err == nil {
+		book.Author = *author
+	}
+	return book
+}
+
+// inTenant reports whether a record of orgID is visible within ctx.
+func inTenant(ctx context.Context, orgID string) bool {
+	tenant, ok := models.TenantFromContext(ctx)
+	return !ok || tenant == orgID
+}
+
+func page[T any](records []T, options ListOptions) []T {
+	if options.Length <= 0 {
+		return nil
+	}
+
+	start := options.offset()
+	if start >= len(records) || start < 0 {
+		return nil
+	}
+
+	end := start + options.Length
+	if end > len(records) {
+		end = len(records)
+	}
+	return records[start:end]
+}
+
+var (
+	_ BookRepository   = (*MemoryBookRepository)(nil)
+	_ AuthorRepository = (*MemoryAuthorRepository)(nil)
+	_ Transactor       = (*MemoryTransactor)(nil)
+)
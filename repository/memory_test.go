@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fahmiyonda007/go-gin-gorm/models"
+)
+
+func TestMemoryListPages(t *testing.T) {
+	ctx := context.Background()
+	authors := NewMemoryAuthorRepository()
+	for _, name := range []string{"Pramoedya", "Multatuli", "Chairil"} {
+		if err := authors.Create(ctx, &models.Author{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		options ListOptions
+		want    int
+	}{
+		{"first page", ListOptions{Page: 1, Length: 2}, 2},
+		{"last page", ListOptions{Page: 2, Length: 2}, 1},
+		{"past the end", ListOptions{Page: 3, Length: 2}, 0},
+		{"zero length", ListOptions{Page: 1, Length: 0}, 0},
+		{"negative length", ListOptions{Page: 1, Length: -1}, 0},
+		{"negative length past the first page", ListOptions{Page: 2, Length: -1}, 0},
+		{"page zero", ListOptions{Page: 0, Length: 2}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, count, err := authors.List(ctx, tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.want || count != 3 {
+				t.Errorf("got %d of %d authors, want %d of 3", len(got), count, tt.want)
+			}
+		})
+	}
+}
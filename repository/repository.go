@@ -0,0 +1,61 @@
+// Package repository stores books and authors. Controllers depend on the
+// BookRepository and AuthorRepository interfaces, backed by GORM in the
+// service and by the in-memory implementations in tests.
+//
+// Every method works within the tenant of its context, see
+// models.WithTenant: records of other tenants are not found and created
+// records belong to the tenant. Within a Transactor's function, they also
+// work within its transaction.
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fahmiyonda007/go-gin-gorm/models"
+)
+
+// ErrNotFound is returned for a record that doesn't exist in the tenant.
+var ErrNotFound = errors.New("record not found")
+
+// ListOptions selects a page of records.
+type ListOptions struct {
+	// Page starts at one.
+	Page   int
+	Length int
+	// OwnerSub, when set, only lists the records created by that user.
+	OwnerSub string
+	// AuthorID, when set, only lists the books of that author.
+	AuthorID uint
+}
+
+func (o ListOptions) offset() int {
+	return (o.Page - 1) * o.Length
+}
+
+// BookRepository stores books. The books it returns have their Author
+// loaded.
+type BookRepository interface {
+	// List returns a page of books, ordered by id, and how many books
+	// there are in total.
+	List(ctx context.Context, options ListOptions) ([]models.Book, int64, error)
+	Get(ctx context.Context, id uint) (*models.Book, error)
+	Create(ctx context.Context, book *models.Book) error
+	// Update saves every field of book.
+	Update(ctx context.Context, book *models.Book) error
+	Delete(ctx context.Context, id uint) error
+	// CountByAuthor returns how many books the author authorID wrote.
+	CountByAuthor(ctx context.Context, authorID uint) (int64, error)
+}
+
+// AuthorRepository stores authors.
+type AuthorRepository interface {
+	// List returns a page of authors, ordered by id, and how many authors
+	// there are in total.
+	List(ctx context.Context, options ListOptions) ([]models.Author, int64, error)
+	Get(ctx context.Context, id uint) (*models.Author, error)
+	Create(ctx context.Context, author *models.Author) error
+	// Update saves every field of author.
+	Update(ctx context.Context, author *models.Author) error
+	Delete(ctx context.Context, id uint) error
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// Transactor runs fn in a transaction. The repositories use the
+// transaction when given the context fn receives, and everything fn did
+// through them is rolled back when it returns an error.
+type Transactor interface {
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txKey struct{}
+
+// GormTransactor is the Transactor of the GORM repositories.
+type GormTransactor struct {
+	db *gorm.DB
+}
+
+// NewGormTransactor creates a GormTransactor on db.
+func NewGormTransactor(db *gorm.DB) *GormTransactor {
+	return &GormTransactor{db: db}
+}
+
+func (t *GormTransactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return conn(ctx, t.db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// conn returns the transaction of ctx, or db outside of one, bound to ctx.
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		db = tx
+	}
+	return db.WithContext(ctx)
+}
+
+// MemoryTransactor is the Transactor of the in-memory repositories. It
+// only serializes transactions, nothing is rolled back.
+type MemoryTransactor struct {
+	mu sync.Mutex
+}
+
+func (t *MemoryTransactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return fn(ctx)
+}
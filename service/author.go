@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fahmiyonda007/go-gin-gorm/models"
+	"github.com/fahmiyonda007/go-gin-gorm/repository"
+)
+
+// AuthorInput is what an author is created or updated from. On update,
+// zero fields are left as they are.
+type AuthorInput struct {
+	Name string
+}
+
+// OnBooks is what deleting an author does to their books.
+type OnBooks string
+
+const (
+	// RestrictBooks refuses to delete an author who has books.
+	RestrictBooks OnBooks = "restrict"
+	// CascadeBooks deletes the books along with the author.
+	CascadeBooks OnBooks = "cascade"
+	// ReassignBooks hands the books over to another author.
+	ReassignBooks OnBooks = "reassign"
+)
+
+// DeleteAuthorOptions tells how to delete an author.
+type DeleteAuthorOptions struct {
+	// OnBooks defaults to RestrictBooks.
+	OnBooks OnBooks
+	// ReassignTo is the author that gets the books with ReassignBooks.
+	ReassignTo uint
+}
+
+// AuthorService manages authors.
+type AuthorService struct {
+	authors repository.AuthorRepository
+	books   repository.BookRepository
+	tx      repository.Transactor
+}
+
+// NewAuthorService creates an AuthorService.
+func NewAuthorService(authors repository.AuthorRepository, books repository.BookRepository, tx repository.Transactor) *AuthorService {
+	return &AuthorService{authors: authors, books: books, tx: tx}
+}
+
+// List returns a page of authors and how many there are in total.
+func (s *AuthorService) List(ctx context.Context, options repository.ListOptions) ([]models.Author, int64, error) {
+	return s.authors.List(ctx, options)
+}
+
+// Get returns the author id.
+func (s *AuthorService) Get(ctx context.Context, id uint) (*models.Author, error) {
+	author, err := s.authors.Get(ctx, id)
+	if err != nil {
+		return nil, notFound(err, "author", id)
+	}
+	return author, nil
+}
+
+// Create creates an author owned by actor.
+func (s *AuthorService) Create(ctx context.Context, actor Actor, input AuthorInput) (*models.Author, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, &ValidationError{Message: "Name is required"}
+	}
+
+	author := models.Author{Name: name, OwnerSub: actor.Subject}
+	if err := s.authors.Create(ctx, &author); err != nil {
+		return nil, err
+	}
+	return &author, nil
+}
+
+// Update renames the author id.
+func (s *AuthorService) Update(ctx context.Context, actor Actor, id uint, input AuthorInput) (*models.Author, error) {
+	var author *models.Author
+	err := s.tx.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		author, err = s.modifiable(ctx, actor, id)
+		if err != nil {
+			return err
+		}
+
+		if input.Name != "" {
+			author.Name = strings.TrimSpace(input.Name)
+			if author.Name == "" {
+				return &ValidationError{Message: "Name can't be blank"}
+			}
+		}
+
+		return notFound(s.authors.Update(ctx, author), "author", id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return author, nil
+}
+
+// Delete deletes the author id, doing to their books what options say.
+// The books are only deleted or reassigned when actor may change every
+// one of them.
+func (s *AuthorService) Delete(ctx context.Context, actor Actor, id uint, options DeleteAuthorOptions) error {
+	switch options.OnBooks {
+	case "":
+		options.OnBooks = RestrictBooks
+	case RestrictBooks, CascadeBooks:
+	case ReassignBooks:
+		if options.ReassignTo == 0 {
+			return &ValidationError{Message: "The author to reassign the books to is required"}
+		}
+		if options.ReassignTo == id {
+			return &ValidationError{Message: "The books can't be reassigned to the deleted author"}
+		}
+	default:
+		return &ValidationError{Message: fmt.Sprintf("onBooks must be %s, %s or %s", RestrictBooks, CascadeBooks, ReassignBooks)}
+	}
+
+	return s.tx.Transaction(ctx, func(ctx context.Context) error {
+		if _, err := s.modifiable(ctx, actor, id); err != nil {
+			return err
+		}
+
+		if err := s.releaseBooks(ctx, actor, id, options); err != nil {
+			return err
+		}
+
+		return notFound(s.authors.Delete(ctx, id), "author", id)
+	})
+}
+
+// releaseBooks deletes or reassigns the books of the author id, so that
+// nothing references them anymore.
+func (s *AuthorService) releaseBooks(ctx context.Context, actor Actor, id uint, options DeleteAuthorOptions) error {
+	count, err := s.books.CountByAuthor(ctx, id)
+	if err != nil || count == 0 {
+		return err
+	}
+
+	if options.OnBooks == RestrictBooks {
+		return &ConflictError{Message: "The author still has books"}
+	}
+
+	if options.OnBooks == ReassignBooks {
+		_, err := s.authors.Get(ctx, options.ReassignTo)
+		if errors.Is(err, repository.ErrNotFound) {
+			return &ValidationError{Message: "Record Author ID not found!"}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	books, _, err := s.books.List(ctx, repository.ListOptions{Page: 1, Length: int(count), AuthorID: id})
+	if err != nil {
+		return err
+	}
+
+	// Check every book first, the in-memory repositories don't roll back.
+	for _, book := range books {
+		if !actor.canModify(book.OwnerSub) {
+			return &ForbiddenError{Message: "Only the owner or an admin can change the books of this author"}
+		}
+	}
+
+	for i := range books {
+		book := &books[i]
+		if options.OnBooks == CascadeBooks {
+			err = s.books.Delete(ctx, book.ID)
+		} else {
+			book.AuthorId = options.ReassignTo
+			err = s.books.Update(ctx, book)
+		}
+		if err != nil {
+			return notFound(err, "book", book.ID)
+		}
+	}
+
+	return nil
+}
+
+// modifiable returns the author id when actor may change them.
+func (s *AuthorService) modifiable(ctx context.Context, actor Actor, id uint) (*models.Author, error) {
+	author, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !actor.canModify(author.OwnerSub) {
+		return nil, &ForbiddenError{Message: "Only the owner or an admin can change this author"}
+	}
+	return author, nil
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fahmiyonda007/go-gin-gorm/migrations"
+	"github.com/fahmiyonda007/go-gin-gorm/models"
+	"github.com/fahmiyonda007/go-gin-gorm/repository"
+)
+
+// backend is a set of repositories the services run on.
+type backend struct {
+	authors repository.AuthorRepository
+	books   repository.BookRepository
+	tx      repository.Transactor
+}
+
+// backends returns the in-memory repositories and the GORM ones on a
+// migrated SQLite database, whose foreign keys restrict deleting authors.
+func backends() map[string]func(t *testing.T) backend {
+	return map[string]func(t *testing.T) backend{
+		"memory": func(t *testing.T) backend {
+			authors := repository.NewMemoryAuthorRepository()
+			return backend{authors, repository.NewMemoryBookRepository(authors), &repository.MemoryTransactor{}}
+		},
+		"sqlite": func(t *testing.T) backend {
+			db, err := models.Open(models.DatabaseConfig{Driver: "sqlite", Name: ":memory:"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := migrations.Up(db); err != nil {
+				t.Fatal(err)
+			}
+			return backend{repository.NewGormAuthorRepository(db), repository.NewGormBookRepository(db), repository.NewGormTransactor(db)}
+		},
+	}
+}
+
+// seed creates two authors of org, the first with a book, and returns
+// their ids and the book's.
+func seed(t *testing.T, b backend, org string) (uint, uint, uint) {
+	t.Helper()
+
+	ctx := models.WithTenant(context.Background(), org)
+	first := models.Author{Name: "Pramoedya", OwnerSub: "auth0|alice"}
+	second := models.Author{Name: "Multatuli", OwnerSub: "auth0|alice"}
+	for _, author := range []*models.Author{&first, &second} {
+		if err := b.authors.Create(ctx, author); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	book := models.Book{Title: "Bumi Manusia", AuthorId: first.ID, OwnerSub: "auth0|bob"}
+	if err := b.books.Create(ctx, &book); err != nil {
+		t.Fatal(err)
+	}
+
+	return first.ID, second.ID, book.ID
+}
+
+func TestAuthorDelete(t *testing.T) {
+	ctx := models.WithTenant(context.Background(), "o1")
+	alice := Actor{Subject: "auth0|alice"}
+
+	for name, open := range backends() {
+		t.Run(name, func(t *testing.T) {
+			t.Run("without books", func(t *testing.T) {
+				b := open(t)
+				_, second, _ := seed(t, b, "o1")
+
+				if err := NewAuthorService(b.authors, b.books, b.tx).Delete(ctx, alice, second, DeleteAuthorOptions{}); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := b.authors.Get(ctx, second); !errors.Is(err, repository.ErrNotFound) {
+					t.Errorf("get the deleted author: %v, want not found", err)
+				}
+			})
+
+			t.Run("restrict", func(t *testing.T) {
+				b := open(t)
+				first, _, book := seed(t, b, "o1")
+
+				err := NewAuthorService(b.authors, b.books, b.tx).Delete(ctx, alice, first, DeleteAuthorOptions{OnBooks: RestrictBooks})
+				var conflict *ConflictError
+				if !errors.As(err, &conflict) {
+					t.Fatalf("err = %v, want a ConflictError", err)
+				}
+				if _, err := b.authors.Get(ctx, first); err != nil {
+					t.Errorf("the author is gone: %v", err)
+				}
+				if _, err := b.books.Get(ctx, book); err != nil {
+					t.Errorf("the book is gone: %v", err)
+				}
+			})
+
+			t.Run("cascade", func(t *testing.T) {
+				b := open(t)
+				first, _, book := seed(t, b, "o1")
+
+				if err := NewAuthorService(b.authors, b.books, b.tx).Delete(ctx, alice, first, DeleteAuthorOptions{OnBooks: CascadeBooks}); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := b.authors.Get(ctx, first); !errors.Is(err, repository.ErrNotFound) {
+					t.Errorf("get the deleted author: %v, want not found", err)
+				}
+				if _, err := b.books.Get(ctx, book); !errors.Is(err, repository.ErrNotFound) {
+					t.Errorf("get the book: %v, want not found", err)
+				}
+			})
+
+			t.Run("reassign", func(t *testing.T) {
+				b := open(t)
+				first, second, book := seed(t, b, "o1")
+
+				err := NewAuthorService(b.authors, b.books, b.tx).Delete(ctx, alice, first, DeleteAuthorOptions{OnBooks: ReassignBooks, ReassignTo: second})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := b.authors.Get(ctx, first); !errors.Is(err, repository.ErrNotFound) {
+					t.Errorf("get the deleted author: %v, want not found", err)
+				}
+				got, err := b.books.Get(ctx, book)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.AuthorId != second {
+					t.Errorf("book author = %d, want %d", got.AuthorId, second)
+				}
+			})
+
+			t.Run("invalid options", func(t *testing.T) {
+				b := open(t)
+				first, _, book := seed(t, b, "o1")
+				other, _, _ := seed(t, b, "o2")
+
+				for _, options := range []DeleteAuthorOptions{
+					{OnBooks: "orphan"},
+					{OnBooks: ReassignBooks},
+					{OnBooks: ReassignBooks, ReassignTo: first},
+					{OnBooks: ReassignBooks, ReassignTo: other},
+				} {
+					err := NewAuthorService(b.authors, b.books, b.tx).Delete(ctx, alice, first, options)
+					var validation *ValidationError
+					if !errors.As(err, &validation) {
+						t.Errorf("%+v: err = %v, want a ValidationError", options, err)
+					}
+				}
+				if got, err := b.books.Get(ctx, book); err != nil || got.AuthorId != first {
+					t.Errorf("book = %+v, %v, want it untouched", got, err)
+				}
+			})
+
+			t.Run("books of another user", func(t *testing.T) {
+				b := open(t)
+				first, second, book := seed(t, b, "o1")
+
+				// Alice owns the authors, but Bob owns the book.
+				actor := Actor{Subject: "auth0|alice", CanModify: func(ownerSub string) bool { return ownerSub == "auth0|alice" }}
+				for _, options := range []DeleteAuthorOptions{
+					{OnBooks: CascadeBooks},
+					{OnBooks: ReassignBooks, ReassignTo: second},
+				} {
+					err := NewAuthorService(b.authors, b.books, b.tx).Delete(ctx, actor, first, options)
+					var forbidden *ForbiddenError
+					if !errors.As(err, &forbidden) {
+						t.Errorf("%s: err = %v, want a ForbiddenError", options.OnBooks, err)
+					}
+				}
+				if got, err := b.books.Get(ctx, book); err != nil || got.AuthorId != first {
+					t.Errorf("book = %+v, %v, want it untouched", got, err)
+				}
+			})
+		})
+	}
+}
+
+// TestAuthorDeleteForeignKey checks that the database refuses deleting an
+// author who still has books, should anything bypass AuthorService.
+func TestAuthorDeleteForeignKey(t *testing.T) {
+	ctx := models.WithTenant(context.Background(), "o1")
+	b := backends()["sqlite"](t)
+	first, _, book := seed(t, b, "o1")
+
+	err := b.authors.Delete(ctx, first)
+	if err == nil || !strings.Contains(err.Error(), "FOREIGN KEY constraint failed") {
+		t.Fatalf("err = %v, want a foreign key violation", err)
+	}
+	if _, err := b.books.Get(ctx, book); err != nil {
+		t.Errorf("the book is gone: %v", err)
+	}
+}
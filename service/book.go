@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/fahmiyonda007/go-gin-gorm/models"
+	"github.com/fahmiyonda007/go-gin-gorm/repository"
+)
+
+// BookInput is what a book is created or updated from. On update, zero
+// fields are left as they are.
+type BookInput struct {
+	Title    string
+	AuthorID uint
+}
+
+// BookService manages books.
+type BookService struct {
+	books   repository.BookRepository
+	authors repository.AuthorRepository
+	tx      repository.Transactor
+}
+
+// NewBookService creates a BookService.
+func NewBookService(books repository.BookRepository, authors repository.AuthorRepository, tx repository.Transactor) *BookService {
+	return &BookService{books: books, authors: authors, tx: tx}
+}
+
+// List returns a page of books and how many there are in total.
+func (s *BookService) List(ctx context.Context, options repository.ListOptions) ([]models.Book, int64, error) {
+	return s.books.List(ctx, options)
+}
+
+// Get returns the book id.
+func (s *BookService) Get(ctx context.Context, id uint) (*models.Book, error) {
+	book, err := s.books.Get(ctx, id)
+	if err != nil {
+		return nil, notFound(err, "book", id)
+	}
+	return book, nil
+}
+
+// Create creates a book written by an existing author, owned by actor.
+func (s *BookService) Create(ctx context.Context, actor Actor, input BookInput) (*models.Book, error) {
+	title := strings.TrimSpace(input.Title)
+	if title == "" {
+		return nil, &ValidationError{Message: "Title is required"}
+	}
+
+	book := models.Book{Title: title, AuthorId: input.AuthorID, OwnerSub: actor.Subject}
+	err := s.tx.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.requireAuthor(ctx, input.AuthorID); err != nil {
+			return err
+		}
+		return s.books.Create(ctx, &book)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &book, nil
+}
+
+// Update changes the title or author of the book id.
+func (s *BookService) Update(ctx context.Context, actor Actor, id uint, input BookInput) (*models.Book, error) {
+	var book *models.Book
+	err := s.tx.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		book, err = s.modifiable(ctx, actor, id)
+		if err != nil {
+			return err
+		}
+
+		if input.Title != "" {
+			book.Title = strings.TrimSpace(input.Title)
+			if book.Title == "" {
+				return &ValidationError{Message: "Title can't be blank"}
+			}
+		}
+		if input.AuthorID != 0 {
+			if err := s.requireAuthor(ctx, input.AuthorID); err != nil {
+				return err
+			}
+			book.AuthorId = input.AuthorID
+		}
+
+		return notFound(s.books.Update(ctx, book), "book", id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return book, nil
+}
+
+// Delete deletes the book id.
+func (s *BookService) Delete(ctx context.Context, actor Actor, id uint) error {
+	return s.tx.Transaction(ctx, func(ctx context.Context) error {
+		if _, err := s.modifiable(ctx, actor, id); err != nil {
+			return err
+		}
+		return notFound(s.books.Delete(ctx, id), "book", id)
+	})
+}
+
+// modifiable returns the book id when actor may change it.
+func (s *BookService) modifiable(ctx context.Context, actor Actor, id uint) (*models.Book, error) {
+	book, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !actor.canModify(book.OwnerSub) {
+		return nil, &ForbiddenError{Message: "Only the owner or an admin can change this book"}
+	}
+	return book, nil
+}
+
+func (s *BookService) requireAuthor(ctx context.Context, authorID uint) error {
+	_, err := s.authors.Get(ctx, authorID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return &ValidationError{Message: "Record Author ID not found!"}
+	}
+	return err
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fahmiyonda007/go-gin-gorm/models"
+	"github.com/fahmiyonda007/go-gin-gorm/repository"
+)
+
+func TestBookCreate(t *testing.T) {
+	ctx := models.WithTenant(context.Background(), "o1")
+	alice := Actor{Subject: "auth0|alice"}
+
+	for name, open := range backends() {
+		t.Run(name, func(t *testing.T) {
+			b := open(t)
+			first, _, _ := seed(t, b, "o1")
+			other, _, _ := seed(t, b, "o2")
+			books := NewBookService(b.books, b.authors, b.tx)
+
+			book, err := books.Create(ctx, alice, BookInput{Title: " Rumah Kaca ", AuthorID: first})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if book.Title != "Rumah Kaca" || book.OwnerSub != "auth0|alice" || book.OrgID != "o1" {
+				t.Errorf("created %+v, want a trimmed title owned by alice in o1", book)
+			}
+
+			for _, tt := range []struct {
+				name  string
+				input BookInput
+			}{
+				{"blank title", BookInput{Title: "  ", AuthorID: first}},
+				{"missing author", BookInput{Title: "Rumah Kaca", AuthorID: 99}},
+				{"author of another org", BookInput{Title: "Rumah Kaca", AuthorID: other}},
+			} {
+				_, err := books.Create(ctx, alice, tt.input)
+				var validation *ValidationError
+				if !errors.As(err, &validation) {
+					t.Errorf("%s: err = %v, want a ValidationError", tt.name, err)
+				}
+			}
+
+			if _, count, err := b.books.List(ctx, repository.ListOptions{Page: 1, Length: 10}); err != nil || count != 2 {
+				t.Errorf("%d books, %v, want the seeded one and the created one", count, err)
+			}
+		})
+	}
+}
+
+func TestBookUpdate(t *testing.T) {
+	ctx := models.WithTenant(context.Background(), "o1")
+	bob := Actor{Subject: "auth0|bob"}
+
+	for name, open := range backends() {
+		t.Run(name, func(t *testing.T) {
+			t.Run("author", func(t *testing.T) {
+				b := open(t)
+				_, second, book := seed(t, b, "o1")
+
+				got, err := NewBookService(b.books, b.authors, b.tx).Update(ctx, bob, book, BookInput{AuthorID: second})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.AuthorId != second || got.Title != "Bumi Manusia" {
+					t.Errorf("updated %+v, want author %d and the title kept", got, second)
+				}
+			})
+
+			t.Run("author of another org", func(t *testing.T) {
+				b := open(t)
+				first, _, book := seed(t, b, "o1")
+				other, _, _ := seed(t, b, "o2")
+
+				_, err := NewBookService(b.books, b.authors, b.tx).Update(ctx, bob, book, BookInput{AuthorID: other})
+				var validation *ValidationError
+				if !errors.As(err, &validation) {
+					t.Fatalf("err = %v, want a ValidationError", err)
+				}
+				if got, err := b.books.Get(ctx, book); err != nil || got.AuthorId != first {
+					t.Errorf("book = %+v, %v, want it untouched", got, err)
+				}
+			})
+
+			t.Run("another org's book", func(t *testing.T) {
+				b := open(t)
+				_, second, _ := seed(t, b, "o1")
+				_, _, book := seed(t, b, "o2")
+
+				_, err := NewBookService(b.books, b.authors, b.tx).Update(ctx, bob, book, BookInput{AuthorID: second})
+				var notFound *NotFoundError
+				if !errors.As(err, &notFound) {
+					t.Errorf("err = %v, want a NotFoundError", err)
+				}
+			})
+		})
+	}
+}
+
+func TestBookOwnership(t *testing.T) {
+	ctx := models.WithTenant(context.Background(), "o1")
+	// Bob owns the seeded book.
+	alice := Actor{Subject: "auth0|alice", CanModify: func(ownerSub string) bool { return ownerSub == "auth0|alice" }}
+	bob := Actor{Subject: "auth0|bob", CanModify: func(ownerSub string) bool { return ownerSub == "auth0|bob" }}
+
+	for name, open := range backends() {
+		t.Run(name, func(t *testing.T) {
+			b := open(t)
+			_, _, book := seed(t, b, "o1")
+			books := NewBookService(b.books, b.authors, b.tx)
+
+			var forbidden *ForbiddenError
+			if _, err := books.Update(ctx, alice, book, BookInput{Title: "Stolen"}); !errors.As(err, &forbidden) {
+				t.Errorf("update by another user: err = %v, want a ForbiddenError", err)
+			}
+			if err := books.Delete(ctx, alice, book); !errors.As(err, &forbidden) {
+				t.Errorf("delete by another user: err = %v, want a ForbiddenError", err)
+			}
+			if got, err := b.books.Get(ctx, book); err != nil || got.Title != "Bumi Manusia" {
+				t.Errorf("book = %+v, %v, want it untouched", got, err)
+			}
+
+			if err := books.Delete(ctx, bob, book); err != nil {
+				t.Errorf("delete by the owner: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"fmt"
+)
+
+// NotFoundError is returned for a record that doesn't exist, or belongs to
+// another organization.
+type NotFoundError struct {
+	Resource string
+	ID       uint
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s %d not found", e.Resource, e.ID)
+}
+
+// ValidationError is returned for input that breaks a rule.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// ForbiddenError is returned when the caller may not change a record.
+type ForbiddenError struct {
+	Message string
+}
+
+func (e *ForbiddenError) Error() string {
+	return e.Message
+}
+
+// ConflictError is returned when the operation clashes with the records
+// as they are, such as deleting an author who still has books.
+type ConflictError struct {
+	Message string
+}
+
+func (e *ConflictError) Error() string {
+	return e.Message
+}
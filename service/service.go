@@ -0,0 +1,31 @@
+// Package service holds the business rules of books and authors. Its
+// services validate input, check who may change what and run multi-step
+// operations in a transaction, on top of the repository package. They
+// report broken rules as the typed errors of this package.
+package service
+
+import (
+	"errors"
+
+	"github.com/fahmiyonda007/go-gin-gorm/repository"
+)
+
+// Actor is who performs an operation.
+type Actor struct {
+	Subject string
+	// CanModify reports whether the actor may change a record created by
+	// ownerSub. Nil allows everything.
+	CanModify func(ownerSub string) bool
+}
+
+func (a Actor) canModify(ownerSub string) bool {
+	return a.CanModify == nil || a.CanModify(ownerSub)
+}
+
+// notFound turns repository.ErrNotFound into a NotFoundError.
+func notFound(err error, resource string, id uint) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return &NotFoundError{Resource: resource, ID: id}
+	}
+	return err
+}